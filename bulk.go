@@ -0,0 +1,123 @@
+// bulk.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AnalyzeMany runs Analyze on all sites in parallel, with no more workers than
+// the number of assessments SSLLabs allows us.  Results are sent as soon as
+// they are available, exactly one per site, and the channel is closed at the end.
+// Assessments failing on the SSLLabs side are tried again with the RetryPolicy,
+// permanent failures are not.  Every site is analyzed with the same options.
+func (c *Client) AnalyzeMany(ctx context.Context, sites []string, o AnalyzeOptions) <-chan LabsResult {
+	results := make(chan LabsResult, len(sites))
+
+	workers := c.workers(ctx, len(sites))
+	c.debug("analyzemany", "sites", len(sites), "workers", workers)
+
+	jobs := make(chan string)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for site := range jobs {
+				lr, err := c.assess(ctx, site, o)
+				if err != nil {
+					results <- LabsResult{Site: site, Err: err}
+					continue
+				}
+				results <- LabsResult{Site: site, Report: lr}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+
+		for i, site := range sites {
+			select {
+			case <-ctx.Done():
+				// Everything not yet started is reported as cancelled
+				for _, s := range sites[i:] {
+					results <- LabsResult{Site: s, Err: errors.Wrap(ctx.Err(), "analyzemany")}
+				}
+				close(jobs)
+				wg.Wait()
+				return
+			case jobs <- site:
+			}
+		}
+		close(jobs)
+		wg.Wait()
+	}()
+	return results
+}
+
+// assess analyzes site, trying again when the assessment failed on the
+// SSLLabs side.  Permanent failures (unknown host, etc.) are reported at once.
+func (c *Client) assess(ctx context.Context, site string, o AnalyzeOptions) (*Host, error) {
+	for retry := 0; ; retry++ {
+		lr, err := c.AnalyzeWithOptions(ctx, site, o)
+		if err == nil || !errors.Is(err, ErrAssessmentFailed) || IsPermanent(err) {
+			return lr, err
+		}
+
+		wait, again := c.retry.Backoff(retry, nil, err)
+		if !again {
+			return lr, err
+		}
+
+		c.verbose("assessment failed, retrying", "site", site, "retry", retry, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return lr, errors.Wrap(ctx.Err(), "analyzemany/wait")
+		case <-time.After(wait):
+		}
+	}
+}
+
+// workers returns the size of the pool, bounded by MaxAssessments
+func (c *Client) workers(ctx context.Context, n int) int {
+	if !c.limiter.isKnown() {
+		if _, err := c.InfoContext(ctx); err != nil {
+			c.debug("analyzemany: no info", "error", err)
+		}
+	}
+
+	c.limiter.mu.Lock()
+	max := c.limiter.max
+	c.limiter.mu.Unlock()
+
+	if max > n {
+		max = n
+	}
+	if max < 1 {
+		max = 1
+	}
+	return max
+}
+
+// Collect waits for all results of AnalyzeMany
+func Collect(results <-chan LabsResult) LabsResults {
+	lr := LabsResults{Errors: map[string]error{}}
+
+	for r := range results {
+		if r.Err != nil {
+			lr.Errors[r.Site] = r.Err
+			continue
+		}
+		lr.Reports = append(lr.Reports, *r.Report)
+	}
+	return lr
+}
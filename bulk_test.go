@@ -0,0 +1,159 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AnalyzeMany(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	sites := []string{"ssllabs.com", "www.ssllabs.com", "example.com", "invalid"}
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	for _, site := range sites[:3] {
+		gock.New(baseURL).
+			Get("/analyze").
+			MatchParam("host", site).
+			Reply(200).
+			BodyString(string(fta))
+	}
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "invalid").
+		Reply(400).
+		BodyString(`{"errors":[{"field":"host","message":"qlue.validation.invalid"}]}`)
+
+	c, err := NewClient(Config{RetryPolicy: NoRetry{}})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	res := Collect(c.AnalyzeMany(context.Background(), sites, DefaultAnalyzeOptions()))
+
+	assert.Len(t, res.Reports, 3)
+	require.Len(t, res.Errors, 1)
+	assert.True(t, errors.Is(res.Errors["invalid"], ErrInvalidHost))
+	assert.True(t, gock.IsDone())
+}
+
+// Permanent failures are not retried, transient ones are
+func TestClient_AnalyzeManyAssessmentError(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	sites := []string{"ssllabs.com", "invalid.example.com"}
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "ssllabs.com").
+		Reply(200).
+		BodyString(`{"host":"ssllabs.com","status":"ERROR","statusMessage":"Running at full capacity. Please try again later."}`)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "ssllabs.com").
+		Reply(200).
+		BodyString(string(fta))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "invalid.example.com").
+		Reply(200).
+		BodyString(`{"host":"invalid.example.com","status":"ERROR","statusMessage":"Unable to resolve domain name"}`)
+
+	c, err := NewClient(Config{RetryPolicy: &ExponentialBackoff{Min: time.Millisecond, Max: time.Millisecond, MaxRetries: 3}})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	res := Collect(c.AnalyzeMany(context.Background(), sites, DefaultAnalyzeOptions()))
+
+	assert.Len(t, res.Reports, 1)
+	require.Len(t, res.Errors, 1)
+	assert.True(t, IsPermanent(res.Errors["invalid.example.com"]))
+	assert.True(t, gock.IsDone())
+}
+
+func TestClient_AnalyzeManyCancelled(t *testing.T) {
+	Before(t)
+
+	sites := []string{"ssllabs.com", "www.ssllabs.com"}
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	n := 0
+	for r := range c.AnalyzeMany(ctx, sites, DefaultAnalyzeOptions()) {
+		assert.Error(t, r.Err)
+		assert.Nil(t, r.Report)
+		n++
+	}
+	assert.Equal(t, len(sites), n)
+}
+
+func TestClient_Workers(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	c.limiter.seed(&Info{MaxAssessments: 3})
+	assert.Equal(t, 3, c.workers(context.Background(), 10))
+	assert.Equal(t, 2, c.workers(context.Background(), 2))
+
+	c.limiter.seed(&Info{MaxAssessments: 0})
+	assert.Equal(t, 1, c.workers(context.Background(), 10))
+}
+
+func TestCollect(t *testing.T) {
+	ch := make(chan LabsResult, 2)
+	ch <- LabsResult{Site: "foo", Report: &Host{Host: "foo"}}
+	ch <- LabsResult{Site: "bar", Err: errors.New("bar")}
+	close(ch)
+
+	res := Collect(ch)
+	require.Len(t, res.Reports, 1)
+	assert.Equal(t, "foo", res.Reports[0].Host)
+	assert.Len(t, res.Errors, 1)
+	assert.Error(t, res.Errors["bar"])
+}
@@ -0,0 +1,190 @@
+// cache.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultCacheSize is the number of reports kept by MemoryCache
+	DefaultCacheSize = 100
+)
+
+// Cache keeps finished reports on our side so that we do not ask SSLLabs again
+// before Host.CacheExpiryTime.  key is derived from the host and the options.
+// It must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*Host, bool)
+	Set(key string, lr *Host) error
+}
+
+// cacheKey is the host and options, without those telling SSLLabs whether to
+// use its own cache as they do not change the report.
+func cacheKey(opts map[string]string) string {
+	v := url.Values{}
+	for k, o := range opts {
+		if k == "startNew" || k == "fromCache" {
+			continue
+		}
+		v.Set(k, o)
+	}
+	// Encode sorts by key
+	return v.Encode()
+}
+
+// expired is true once CacheExpiryTime is reached, reports without one are
+// always expired.
+func expired(lr *Host) bool {
+	return lr.CacheExpiryTime.IsZero() || time.Now().After(lr.CacheExpiryTime.Time)
+}
+
+// MemoryCache is an in-memory LRU cache, safe for concurrent use
+type MemoryCache struct {
+	mu    sync.Mutex
+	size  int
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+// memEntry is what is stored in the list
+type memEntry struct {
+	key string
+	lr  Host
+}
+
+// NewMemoryCache returns a cache keeping at most size reports, DefaultCacheSize
+// if size is 0 or less.
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &MemoryCache{
+		size:  size,
+		lru:   list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// Get implements Cache, expired reports are removed
+func (m *MemoryCache) Get(key string) (*Host, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	ent := el.Value.(*memEntry)
+	if expired(&ent.lr) {
+		m.lru.Remove(el)
+		delete(m.items, key)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(el)
+	lr := ent.lr
+	return &lr, true
+}
+
+// Set implements Cache, the least recently used report goes if we are full
+func (m *MemoryCache) Set(key string, lr *Host) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		el.Value.(*memEntry).lr = *lr
+		m.lru.MoveToFront(el)
+		return nil
+	}
+
+	m.items[key] = m.lru.PushFront(&memEntry{key: key, lr: *lr})
+	if m.lru.Len() > m.size {
+		old := m.lru.Back()
+		m.lru.Remove(old)
+		delete(m.items, old.Value.(*memEntry).key)
+	}
+	return nil
+}
+
+// Len is the number of reports in the cache
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// FileCache stores every report as a JSON file in a directory, so that it
+// survives between runs.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates dir if needed
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "NewFileCache")
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// path is where the report for key is stored
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements Cache, expired or unreadable reports are removed
+func (f *FileCache) Get(key string) (*Host, bool) {
+	file := f.path(key)
+
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+
+	var lr Host
+
+	if err := json.Unmarshal(raw, &lr); err != nil || expired(&lr) {
+		os.Remove(file)
+		return nil, false
+	}
+	return &lr, true
+}
+
+// Set implements Cache, the file is replaced atomically
+func (f *FileCache) Set(key string, lr *Host) error {
+	raw, err := json.Marshal(lr)
+	if err != nil {
+		return errors.Wrap(err, "cache/marshal")
+	}
+
+	tmp, err := ioutil.TempFile(f.dir, "report-*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "cache/create")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "cache/write")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "cache/close")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), f.path(key)), "cache/rename")
+}
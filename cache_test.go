@@ -0,0 +1,183 @@
+package ssllabs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// in returns the time d from now
+func in(d time.Duration) Timestamp {
+	return NewTimestamp(time.Now().Add(d))
+}
+
+func TestCacheKey(t *testing.T) {
+	k1 := cacheKey(map[string]string{"host": "ssllabs.com", "all": "done", "fromCache": "on"})
+	k2 := cacheKey(map[string]string{"all": "done", "host": "ssllabs.com", "startNew": "on", "fromCache": "off"})
+	k3 := cacheKey(map[string]string{"host": "ssllabs.com"})
+
+	assert.Equal(t, "all=done&host=ssllabs.com", k1)
+	assert.Equal(t, k1, k2)
+	assert.NotEqual(t, k1, k3)
+}
+
+func TestExpired(t *testing.T) {
+	assert.True(t, expired(&Host{}))
+	assert.True(t, expired(&Host{CacheExpiryTime: in(-time.Minute)}))
+	assert.False(t, expired(&Host{CacheExpiryTime: in(time.Minute)}))
+}
+
+func TestMemoryCache(t *testing.T) {
+	m := NewMemoryCache(2)
+
+	_, ok := m.Get("foo")
+	assert.False(t, ok)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Set(fmt.Sprintf("k%d", i), &Host{Host: fmt.Sprintf("h%d", i), CacheExpiryTime: in(time.Hour)}))
+	}
+	assert.Equal(t, 2, m.Len())
+
+	// k0 was the oldest
+	_, ok = m.Get("k0")
+	assert.False(t, ok)
+
+	lr, ok := m.Get("k1")
+	require.True(t, ok)
+	assert.Equal(t, "h1", lr.Host)
+
+	// k1 is now the most recent so k2 goes
+	require.NoError(t, m.Set("k3", &Host{Host: "h3", CacheExpiryTime: in(time.Hour)}))
+	_, ok = m.Get("k2")
+	assert.False(t, ok)
+	_, ok = m.Get("k1")
+	assert.True(t, ok)
+}
+
+func TestMemoryCache_Expired(t *testing.T) {
+	m := NewMemoryCache(0)
+	assert.Equal(t, DefaultCacheSize, m.size)
+
+	require.NoError(t, m.Set("foo", &Host{Host: "foo", CacheExpiryTime: in(-time.Second)}))
+	_, ok := m.Get("foo")
+	assert.False(t, ok)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestFileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssllabs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f, err := NewFileCache(filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+
+	_, ok := f.Get("foo")
+	assert.False(t, ok)
+
+	lr := &Host{Host: "ssllabs.com", Status: "READY", CacheExpiryTime: in(time.Hour), Endpoints: []Endpoint{{Grade: "A+"}}}
+	require.NoError(t, f.Set("foo", lr))
+
+	// Another instance, as in a later run
+	f2, err := NewFileCache(filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+
+	got, ok := f2.Get("foo")
+	require.True(t, ok)
+
+	// Raw is what was read from the file
+	assert.NotEmpty(t, got.Raw)
+	got.Raw, got.Endpoints[0].Raw = nil, nil
+	assert.EqualValues(t, lr, got)
+
+	require.NoError(t, f.Set("foo", &Host{Host: "ssllabs.com", CacheExpiryTime: in(-time.Hour)}))
+	_, ok = f2.Get("foo")
+	assert.False(t, ok)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestClient_AnalyzeCached(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+	body := fmt.Sprintf(`{"host":"ssllabs.com","status":"READY","cacheExpiryTime":%d,"endpoints":[{"ipAddress":"64.41.200.100","statusMessage":"Ready","grade":"A+"}]}`, in(time.Hour).Millis())
+
+	// Only once
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", site).
+		Reply(200).
+		BodyString(body)
+
+	c, err := NewClient(Config{Cache: NewMemoryCache(10)})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	for i := 0; i < 3; i++ {
+		grade, err := c.GetGradeWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+		require.NoError(t, err)
+		assert.Equal(t, "A+", grade)
+	}
+	assert.True(t, gock.IsDone())
+}
+
+func TestClient_AnalyzeCachedForce(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", site).
+		Times(2).
+		Reply(200).
+		BodyString(fmt.Sprintf(`{"host":"ssllabs.com","status":"READY","cacheExpiryTime":%d,"endpoints":[{"ipAddress":"64.41.200.100","statusMessage":"Ready","grade":"B"}]}`, in(time.Hour).Millis()))
+
+	cache := NewMemoryCache(10)
+	require.NoError(t, cache.Set(cacheKey(map[string]string{
+		"host":           site,
+		"publish":        "off",
+		"maxAge":         "24",
+		"ignoreMismatch": "on",
+	}), &Host{Host: site, CacheExpiryTime: in(time.Hour), Endpoints: []Endpoint{{StatusMessage: "Ready", Grade: "A+"}}}))
+
+	c, err := NewClient(Config{Cache: cache, Force: true})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	grade, err := c.GetGradeWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "B", grade)
+	assert.True(t, gock.IsDone())
+
+	// The fresh report replaced the old one
+	assert.Equal(t, 1, cache.Len())
+}
@@ -8,12 +8,13 @@ This is just a very short example.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/keltia/ssllabs"
 )
@@ -27,10 +28,13 @@ const (
 )
 
 var (
+	fEmail       string
 	fDebug       bool
 	fDetailed    bool
 	fForce       bool
 	fInfo        bool
+	fRecord      string
+	fReplay      string
 	fVerbose     bool
 	fShowVersion bool
 
@@ -40,8 +44,11 @@ var (
 
 func init() {
 	flag.BoolVar(&fDetailed, "d", false, "Get a detailed report")
+	flag.StringVar(&fEmail, "e", "", "Registered email, switches to API v4")
 	flag.BoolVar(&fForce, "F", false, "Do not use SSLLabs cache")
 	flag.BoolVar(&fInfo, "I", false, "Get SSLLabs info.")
+	flag.StringVar(&fRecord, "R", "", "Record the conversation in this directory")
+	flag.StringVar(&fReplay, "P", "", "Replay the conversation recorded in this directory")
 	flag.BoolVar(&fVerbose, "v", false, "Verbose mode")
 	flag.BoolVar(&fDebug, "D", false, "Debug mode")
 	flag.BoolVar(&fShowVersion, "V", false, "Display version & exit.")
@@ -68,6 +75,28 @@ func main() {
 		cfg.Force = true
 	}
 
+	// Keep reports between runs, -F still starts a new assessment
+	if dir, err := os.UserCacheDir(); err == nil {
+		if fc, err := ssllabs.NewFileCache(filepath.Join(dir, MyName)); err == nil {
+			cfg.Cache = fc
+		}
+	}
+
+	if fRecord != "" {
+		cfg.Cassette = fRecord
+		cfg.CassetteMode = ssllabs.ModeRecord
+	}
+
+	if fReplay != "" {
+		cfg.Cassette = fReplay
+		cfg.CassetteMode = ssllabs.ModeReplay
+	}
+
+	if fEmail != "" {
+		cfg.APIVersion = 4
+		cfg.Email = fEmail
+	}
+
 	// Setup client
 	c, err := ssllabs.NewClient(cfg)
 	if err != nil {
@@ -75,8 +104,8 @@ func main() {
 	}
 
 	if fShowVersion {
-		fmt.Fprintf(os.Stderr, "%s/%s API/%s(v3)\n",
-			MyName, MyVersion, ssllabs.Version())
+		fmt.Fprintf(os.Stderr, "%s/%s API/%s(v%d)\n",
+			MyName, MyVersion, ssllabs.Version(), c.APIVersion())
 		os.Exit(0)
 	}
 
@@ -94,7 +123,17 @@ func main() {
 		log.Fatalf("You must give at least one site name!")
 	}
 
-	report, err := c.GetDetailedReport(site)
+	ctx := context.Background()
+	opts := ssllabs.DefaultAnalyzeOptions()
+
+	// Show where we are during long scans
+	if fVerbose {
+		opts.Progress = func(ev ssllabs.ProgressEvent) {
+			fmt.Fprintf(os.Stderr, "%s: %s %d%%\n", ev.Host, ev.Status, ev.Progress())
+		}
+	}
+
+	report, err := c.GetDetailedReportWithOptions(ctx, site, opts)
 	if err != nil {
 		log.Fatalf("impossible to get grade for '%s': %v\n", site, err)
 	}
@@ -103,14 +142,18 @@ func main() {
 		MyName, MyVersion, ssllabs.Version())
 
 	if fDetailed {
-		// Just dump the json
-		fmt.Printf("%v\n", report)
+		// Just dump the json, as sent by SSLLabs
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("impossible to dump report for '%s': %v\n", site, err)
+		}
+		fmt.Printf("%s\n", out)
 	} else {
-		grade, err := c.GetGrade(site)
+		// Same policy as the client, no need to ask again
+		grade, err := cfg.GradePolicy.Grade(&report)
 		if err != nil {
 			log.Fatalf("impossible to get grade for '%s': %v\n", site, err)
 		}
-		d := time.Unix(report.TestTime/1000, 0).Local()
-		fmt.Printf("Grade for '%s' is %s (%s)\n", site, grade, d)
+		fmt.Printf("Grade for '%s' is %s (%s)\n", site, grade, report.TestTime.Local())
 	}
 }
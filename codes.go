@@ -0,0 +1,597 @@
+// codes.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+The integer codes and bitmasks of EndpointDetails and Cert, as described in the
+API documentation.  They are still sent and read as numbers so reports are
+unchanged on the wire, String() is for humans.
+*/
+
+// flags returns the names of the bits set in v, joined with "|", names[i]
+// being bit i.  Unknown bits are shown in hex.
+func flags(v int, names []string) string {
+	if v == 0 {
+		return "none"
+	}
+
+	var list []string
+	for i, n := range names {
+		if v&(1<<uint(i)) != 0 && n != "" {
+			list = append(list, n)
+			v &^= 1 << uint(i)
+		}
+	}
+	if v != 0 {
+		list = append(list, fmt.Sprintf("%#x", v))
+	}
+	return strings.Join(list, "|")
+}
+
+// enum returns the name of v in names, or T(v) if there is none
+func enum(typ string, v int, names map[int]string) string {
+	if n, ok := names[v]; ok {
+		return n
+	}
+	return fmt.Sprintf("%s(%d)", typ, v)
+}
+
+// RenegSupport is the renegSupport bitmask
+type RenegSupport int
+
+const (
+	// RenegInsecureClient is insecure client-initiated renegotiation
+	RenegInsecureClient RenegSupport = 1 << iota
+	// RenegSecure is secure renegotiation
+	RenegSecure
+	// RenegSecureClient is secure client-initiated renegotiation
+	RenegSecureClient
+	// RenegSecureRequired means the server requires secure renegotiation
+	RenegSecureRequired
+)
+
+// Has is true if all of f are set
+func (r RenegSupport) Has(f RenegSupport) bool {
+	return r&f == f
+}
+
+// String implements fmt.Stringer
+func (r RenegSupport) String() string {
+	return flags(int(r), []string{"insecure-client", "secure", "secure-client", "secure-required"})
+}
+
+// SessionResumption is the sessionResumption code
+type SessionResumption int
+
+const (
+	// ResumptionDisabled is for empty session IDs
+	ResumptionDisabled SessionResumption = iota
+	// ResumptionIDsOnly is for session IDs sent but sessions not resumed
+	ResumptionIDsOnly
+	// ResumptionEnabled is for working session resumption
+	ResumptionEnabled
+)
+
+// String implements fmt.Stringer
+func (s SessionResumption) String() string {
+	return enum("SessionResumption", int(s), map[int]string{
+		0: "disabled",
+		1: "ids-only",
+		2: "enabled",
+	})
+}
+
+// SessionTickets is the sessionTickets bitmask
+type SessionTickets int
+
+const (
+	// TicketsSupported is set if session tickets are supported
+	TicketsSupported SessionTickets = 1 << iota
+	// TicketsFaulty is set if the implementation is faulty
+	TicketsFaulty
+	// TicketsIntolerant is set if the server is intolerant to the extension
+	TicketsIntolerant
+)
+
+// Has is true if all of f are set
+func (s SessionTickets) Has(f SessionTickets) bool {
+	return s&f == f
+}
+
+// String implements fmt.Stringer
+func (s SessionTickets) String() string {
+	return flags(int(s), []string{"supported", "faulty", "intolerant"})
+}
+
+// ForwardSecrecy is the forwardSecrecy bitmask
+type ForwardSecrecy int
+
+const (
+	// FSSome is set if at least one browser from the simulations negotiated a FS suite
+	FSSome ForwardSecrecy = 1 << iota
+	// FSModern is set if FS is achieved with modern clients
+	FSModern
+	// FSAll is set if all simulated clients achieve FS
+	FSAll
+)
+
+// Has is true if all of f are set
+func (f ForwardSecrecy) Has(g ForwardSecrecy) bool {
+	return f&g == g
+}
+
+// String implements fmt.Stringer
+func (f ForwardSecrecy) String() string {
+	return flags(int(f), []string{"some", "modern", "all"})
+}
+
+// ProtocolIntolerance is the protocolIntolerance bitmask
+type ProtocolIntolerance int
+
+const (
+	// IntolerantTLS10 is TLS 1.0 intolerance
+	IntolerantTLS10 ProtocolIntolerance = 1 << iota
+	// IntolerantTLS11 is TLS 1.1 intolerance
+	IntolerantTLS11
+	// IntolerantTLS12 is TLS 1.2 intolerance
+	IntolerantTLS12
+	// IntolerantTLS13 is TLS 1.3 intolerance
+	IntolerantTLS13
+	// IntolerantTLS152 is TLS 1.152 intolerance
+	IntolerantTLS152
+	// IntolerantTLS252 is TLS 2.152 intolerance
+	IntolerantTLS252
+)
+
+// Has is true if all of f are set
+func (p ProtocolIntolerance) Has(f ProtocolIntolerance) bool {
+	return p&f == f
+}
+
+// String implements fmt.Stringer
+func (p ProtocolIntolerance) String() string {
+	return flags(int(p), []string{"TLS1.0", "TLS1.1", "TLS1.2", "TLS1.3", "TLS1.152", "TLS2.152"})
+}
+
+// MiscIntolerance is the miscIntolerance bitmask
+type MiscIntolerance int
+
+const (
+	// IntolerantExtension is extension intolerance
+	IntolerantExtension MiscIntolerance = 1 << iota
+	// IntolerantLongHandshake is long handshake intolerance
+	IntolerantLongHandshake
+	// LongHandshakeWorkaround is set if the workaround for the above worked
+	LongHandshakeWorkaround
+)
+
+// Has is true if all of f are set
+func (m MiscIntolerance) Has(f MiscIntolerance) bool {
+	return m&f == f
+}
+
+// String implements fmt.Stringer
+func (m MiscIntolerance) String() string {
+	return flags(int(m), []string{"extension", "long-handshake", "long-handshake-workaround"})
+}
+
+// Names shared by the vulnerability tests
+const (
+	nameFailed        = "test-failed"
+	nameUnknown       = "unknown"
+	nameNotVulnerable = "not-vulnerable"
+	nameVulnerable    = "vulnerable"
+	nameExploitable   = "exploitable"
+)
+
+// OpenSSLCcs is the openSslCcs code (CVE-2014-0224)
+type OpenSSLCcs int
+
+const (
+	// CcsFailed means the test failed
+	CcsFailed OpenSSLCcs = iota - 1
+	// CcsUnknown means we do not know
+	CcsUnknown
+	// CcsNotVulnerable means not vulnerable
+	CcsNotVulnerable
+	// CcsPossiblyVulnerable means possibly vulnerable, but not exploitable
+	CcsPossiblyVulnerable
+	// CcsVulnerable means vulnerable and exploitable
+	CcsVulnerable
+)
+
+// Vulnerable is true if exploitable
+func (o OpenSSLCcs) Vulnerable() bool {
+	return o == CcsVulnerable
+}
+
+// String implements fmt.Stringer
+func (o OpenSSLCcs) String() string {
+	return enum("OpenSSLCcs", int(o), map[int]string{
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  "possibly-vulnerable",
+		3:  nameExploitable,
+	})
+}
+
+// LuckyMinus20 is the openSSLLuckyMinus20 code (CVE-2016-2107)
+type LuckyMinus20 int
+
+const (
+	// LuckyFailed means the test failed
+	LuckyFailed LuckyMinus20 = iota - 1
+	// LuckyUnknown means we do not know
+	LuckyUnknown
+	// LuckyNotVulnerable means not vulnerable
+	LuckyNotVulnerable
+	// LuckyVulnerable means vulnerable and insecure
+	LuckyVulnerable
+)
+
+// Vulnerable is true if vulnerable
+func (l LuckyMinus20) Vulnerable() bool {
+	return l == LuckyVulnerable
+}
+
+// String implements fmt.Stringer
+func (l LuckyMinus20) String() string {
+	return enum("LuckyMinus20", int(l), map[int]string{
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  nameVulnerable,
+	})
+}
+
+// Ticketbleed is the ticketbleed code (CVE-2016-9244)
+type Ticketbleed int
+
+const (
+	// TicketbleedFailed means the test failed
+	TicketbleedFailed Ticketbleed = iota - 1
+	// TicketbleedUnknown means we do not know
+	TicketbleedUnknown
+	// TicketbleedNotVulnerable means not vulnerable
+	TicketbleedNotVulnerable
+	// TicketbleedVulnerable means vulnerable and insecure
+	TicketbleedVulnerable
+	// TicketbleedSimilar means not vulnerable but a similar bug was found
+	TicketbleedSimilar
+)
+
+// Vulnerable is true if vulnerable
+func (t Ticketbleed) Vulnerable() bool {
+	return t == TicketbleedVulnerable
+}
+
+// String implements fmt.Stringer
+func (t Ticketbleed) String() string {
+	return enum("Ticketbleed", int(t), map[int]string{
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  nameVulnerable,
+		3:  "similar-bug",
+	})
+}
+
+// Bleichenbacher is the bleichenbacher code (ROBOT)
+type Bleichenbacher int
+
+const (
+	// BleichenbacherFailed means the test failed
+	BleichenbacherFailed Bleichenbacher = iota - 1
+	// BleichenbacherUnknown means we do not know
+	BleichenbacherUnknown
+	// BleichenbacherNotVulnerable means not vulnerable
+	BleichenbacherNotVulnerable
+	// BleichenbacherWeakOracle means vulnerable with a weak oracle
+	BleichenbacherWeakOracle
+	// BleichenbacherStrongOracle means vulnerable with a strong oracle
+	BleichenbacherStrongOracle
+	// BleichenbacherInconsistent means the results were inconsistent
+	BleichenbacherInconsistent
+)
+
+// Vulnerable is true if there is an oracle, weak or strong
+func (b Bleichenbacher) Vulnerable() bool {
+	return b == BleichenbacherWeakOracle || b == BleichenbacherStrongOracle
+}
+
+// String implements fmt.Stringer
+func (b Bleichenbacher) String() string {
+	return enum("Bleichenbacher", int(b), map[int]string{
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  "weak-oracle",
+		3:  "strong-oracle",
+		4:  "inconsistent",
+	})
+}
+
+// PaddingOracle is the code of the CBC padding oracle tests: zombiePoodle,
+// goldenDoodle, zeroLengthPaddingOracle and sleepingPoodle.  They share -1 to 1
+// but each has its own values for vulnerable and exploitable.
+type PaddingOracle int
+
+const (
+	// PaddingOracleFailed means the test failed
+	PaddingOracleFailed PaddingOracle = -1
+	// PaddingOracleUnknown means we do not know
+	PaddingOracleUnknown PaddingOracle = 0
+	// PaddingOracleNotVulnerable means not vulnerable
+	PaddingOracleNotVulnerable PaddingOracle = 1
+	// PaddingOracleVulnerable means vulnerable (zombie POODLE)
+	PaddingOracleVulnerable PaddingOracle = 2
+	// PaddingOracleExploitable means vulnerable and exploitable (zombie POODLE)
+	PaddingOracleExploitable PaddingOracle = 3
+	// GoldenVulnerable means vulnerable (GOLDENDOODLE)
+	GoldenVulnerable PaddingOracle = 4
+	// GoldenExploitable means vulnerable and exploitable (GOLDENDOODLE)
+	GoldenExploitable PaddingOracle = 5
+	// ZeroLengthVulnerable means vulnerable (0-length padding oracle)
+	ZeroLengthVulnerable PaddingOracle = 6
+	// ZeroLengthExploitable means vulnerable and exploitable (0-length padding oracle)
+	ZeroLengthExploitable PaddingOracle = 7
+	// SleepingVulnerable means vulnerable (sleeping POODLE)
+	SleepingVulnerable PaddingOracle = 10
+	// SleepingExploitable means vulnerable and exploitable (sleeping POODLE)
+	SleepingExploitable PaddingOracle = 11
+)
+
+// Vulnerable is true if vulnerable, exploitable or not
+func (p PaddingOracle) Vulnerable() bool {
+	return p > PaddingOracleNotVulnerable
+}
+
+// Exploitable is true if vulnerable and exploitable
+func (p PaddingOracle) Exploitable() bool {
+	switch p {
+	case PaddingOracleExploitable, GoldenExploitable, ZeroLengthExploitable, SleepingExploitable:
+		return true
+	}
+	return false
+}
+
+// String implements fmt.Stringer
+func (p PaddingOracle) String() string {
+	return enum("PaddingOracle", int(p), map[int]string{
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  nameVulnerable,
+		3:  nameExploitable,
+		4:  nameVulnerable,
+		5:  nameExploitable,
+		6:  nameVulnerable,
+		7:  nameExploitable,
+		10: nameVulnerable,
+		11: nameExploitable,
+	})
+}
+
+// PoodleTLS is the poodleTLS code
+type PoodleTLS int
+
+const (
+	// PoodleTLSTimeout means the test timed out
+	PoodleTLSTimeout PoodleTLS = iota - 3
+	// PoodleTLSNoTLS means TLS is not supported
+	PoodleTLSNoTLS
+	// PoodleTLSFailed means the test failed
+	PoodleTLSFailed
+	// PoodleTLSUnknown means we do not know
+	PoodleTLSUnknown
+	// PoodleTLSNotVulnerable means not vulnerable
+	PoodleTLSNotVulnerable
+	// PoodleTLSVulnerable means vulnerable
+	PoodleTLSVulnerable
+)
+
+// Vulnerable is true if vulnerable
+func (p PoodleTLS) Vulnerable() bool {
+	return p == PoodleTLSVulnerable
+}
+
+// String implements fmt.Stringer
+func (p PoodleTLS) String() string {
+	return enum("PoodleTLS", int(p), map[int]string{
+		-3: "timeout",
+		-2: "tls-not-supported",
+		-1: nameFailed,
+		0:  nameUnknown,
+		1:  nameNotVulnerable,
+		2:  nameVulnerable,
+	})
+}
+
+// SctSources is the hasSct bitmask, where the Signed Certificate Timestamps were found
+type SctSources int
+
+const (
+	// SctInCert is for SCT in the certificate
+	SctInCert SctSources = 1 << iota
+	// SctInOcsp is for SCT in the stapled OCSP response
+	SctInOcsp
+	// SctInTLS is for SCT in the TLS extension
+	SctInTLS
+)
+
+// Has is true if all of f are set
+func (s SctSources) Has(f SctSources) bool {
+	return s&f == f
+}
+
+// String implements fmt.Stringer
+func (s SctSources) String() string {
+	return flags(int(s), []string{"certificate", "ocsp", "tls"})
+}
+
+// DhKnownPrimes is the dhUsesKnownPrimes code
+type DhKnownPrimes int
+
+const (
+	// DhPrimesUnknown means the primes are not known ones
+	DhPrimesUnknown DhKnownPrimes = iota
+	// DhPrimesKnown means known primes, not weak
+	DhPrimesKnown
+	// DhPrimesWeak means known and weak primes
+	DhPrimesWeak
+)
+
+// String implements fmt.Stringer
+func (d DhKnownPrimes) String() string {
+	return enum("DhKnownPrimes", int(d), map[int]string{
+		0: "no",
+		1: "known",
+		2: "weak",
+	})
+}
+
+// RevocationStatus is the result of a revocation check, used for certificates
+// and OCSP stapling.
+type RevocationStatus int
+
+const (
+	// RevocationNotChecked means not checked
+	RevocationNotChecked RevocationStatus = iota
+	// RevocationRevoked means the certificate is revoked
+	RevocationRevoked
+	// RevocationNotRevoked means the certificate is not revoked
+	RevocationNotRevoked
+	// RevocationCheckError means the check failed
+	RevocationCheckError
+	// RevocationNoInfo means there is no revocation information
+	RevocationNoInfo
+	// RevocationInternalError means SSLLabs had an internal error
+	RevocationInternalError
+)
+
+// String implements fmt.Stringer
+func (r RevocationStatus) String() string {
+	return enum("RevocationStatus", int(r), map[int]string{
+		0: "not-checked",
+		1: "revoked",
+		2: "not-revoked",
+		3: "check-error",
+		4: "no-info",
+		5: "internal-error",
+	})
+}
+
+// RevocationInfo is the revocationInfo bitmask
+type RevocationInfo int
+
+const (
+	// RevocationCRL is set if the certificate has CRL information
+	RevocationCRL RevocationInfo = 1 << iota
+	// RevocationOCSP is set if the certificate has OCSP information
+	RevocationOCSP
+)
+
+// Has is true if all of f are set
+func (r RevocationInfo) Has(f RevocationInfo) bool {
+	return r&f == f
+}
+
+// String implements fmt.Stringer
+func (r RevocationInfo) String() string {
+	return flags(int(r), []string{"crl", "ocsp"})
+}
+
+// CertIssues is the issues bitmask of Cert
+type CertIssues int
+
+const (
+	// CertNoTrust is set if there is no chain of trust
+	CertNoTrust CertIssues = 1 << iota
+	// CertNotBefore is set if the certificate is not valid yet
+	CertNotBefore
+	// CertNotAfter is set if the certificate has expired
+	CertNotAfter
+	// CertHostnameMismatch is set if the hostname does not match
+	CertHostnameMismatch
+	// CertRevoked is set if the certificate is revoked
+	CertRevoked
+	// CertBadCommonName is set if the common name is bad
+	CertBadCommonName
+	// CertSelfSigned is set if the certificate is self-signed
+	CertSelfSigned
+	// CertBlacklisted is set if the certificate is blacklisted
+	CertBlacklisted
+	// CertInsecureSignature is set if the signature is insecure
+	CertInsecureSignature
+	// CertInsecureKey is set if the key is insecure
+	CertInsecureKey
+)
+
+// Has is true if all of f are set
+func (c CertIssues) Has(f CertIssues) bool {
+	return c&f == f
+}
+
+// String implements fmt.Stringer
+func (c CertIssues) String() string {
+	return flags(int(c), []string{"no-trust", "not-before", "not-after", "hostname-mismatch", "revoked",
+		"bad-common-name", "self-signed", "blacklisted", "insecure-signature", "insecure-key"})
+}
+
+// ChainIssues is the issues bitmask of CertificateChain, bit 0 is unused
+type ChainIssues int
+
+const (
+	// ChainIncomplete is set if missing intermediates had to be fetched elsewhere
+	ChainIncomplete ChainIssues = 1 << (iota + 1)
+	// ChainUnrelated is set if the chain has unrelated or duplicate certificates
+	ChainUnrelated
+	// ChainBadOrder is set if the order is incorrect
+	ChainBadOrder
+	// ChainSelfSignedRoot is set if the chain contains a self-signed root
+	ChainSelfSignedRoot
+	// ChainNotValidated is set if the certificates form a chain that could not be validated
+	ChainNotValidated
+)
+
+// Has is true if all of f are set
+func (c ChainIssues) Has(f ChainIssues) bool {
+	return c&f == f
+}
+
+// String implements fmt.Stringer
+func (c ChainIssues) String() string {
+	return flags(int(c), []string{"", "incomplete", "unrelated", "bad-order", "self-signed-root", "not-validated"})
+}
+
+// ZeroRTT is the zeroRTTEnabled code, TLS 1.3 early data
+type ZeroRTT int
+
+const (
+	// ZeroRTTError means the test failed
+	ZeroRTTError ZeroRTT = iota - 2
+	// ZeroRTTNotTested means TLS 1.3 is not supported
+	ZeroRTTNotTested
+	// ZeroRTTDisabled means 0-RTT is disabled
+	ZeroRTTDisabled
+	// ZeroRTTEnabled means 0-RTT is enabled
+	ZeroRTTEnabled
+)
+
+// String implements fmt.Stringer
+func (z ZeroRTT) String() string {
+	return enum("ZeroRTT", int(z), map[int]string{
+		-2: "error",
+		-1: "not-tested",
+		0:  "disabled",
+		1:  "enabled",
+	})
+}
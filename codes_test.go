@@ -0,0 +1,128 @@
+package ssllabs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags_String(t *testing.T) {
+	td := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{RenegSupport(0), "none"},
+		{RenegSecure, "secure"},
+		{RenegSecure | RenegSecureRequired, "secure|secure-required"},
+		{RenegSupport(0x12), "secure|0x10"},
+		{TicketsSupported | TicketsFaulty, "supported|faulty"},
+		{FSAll | FSModern | FSSome, "some|modern|all"},
+		{IntolerantTLS13, "TLS1.3"},
+		{IntolerantExtension | LongHandshakeWorkaround, "extension|long-handshake-workaround"},
+		{SctInCert | SctInTLS, "certificate|tls"},
+		{RevocationCRL | RevocationOCSP, "crl|ocsp"},
+		{CertNotAfter | CertHostnameMismatch, "not-after|hostname-mismatch"},
+		{CertInsecureKey, "insecure-key"},
+		{ChainIncomplete | ChainBadOrder, "incomplete|bad-order"},
+		{ChainIssues(1), "0x1"},
+	}
+
+	for _, d := range td {
+		assert.Equal(t, d.want, d.v.String())
+	}
+}
+
+func TestFlags_Has(t *testing.T) {
+	r := RenegSecure | RenegSecureClient
+
+	assert.True(t, r.Has(RenegSecure))
+	assert.True(t, r.Has(RenegSecure|RenegSecureClient))
+	assert.False(t, r.Has(RenegInsecureClient))
+	assert.False(t, r.Has(RenegSecure|RenegSecureRequired))
+
+	assert.True(t, ChainIssues(18).Has(ChainIncomplete|ChainSelfSignedRoot))
+	assert.True(t, CertIssues(6).Has(CertNotAfter))
+	assert.False(t, SctSources(2).Has(SctInCert))
+}
+
+func TestEnums_String(t *testing.T) {
+	td := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{ResumptionEnabled, "enabled"},
+		{SessionResumption(9), "SessionResumption(9)"},
+		{CcsFailed, "test-failed"},
+		{CcsPossiblyVulnerable, "possibly-vulnerable"},
+		{LuckyVulnerable, "vulnerable"},
+		{TicketbleedSimilar, "similar-bug"},
+		{BleichenbacherStrongOracle, "strong-oracle"},
+		{PaddingOracleNotVulnerable, "not-vulnerable"},
+		{SleepingExploitable, "exploitable"},
+		{GoldenVulnerable, "vulnerable"},
+		{GoldenExploitable, "exploitable"},
+		{PaddingOracle(8), "PaddingOracle(8)"},
+		{PoodleTLSTimeout, "timeout"},
+		{PoodleTLSNoTLS, "tls-not-supported"},
+		{DhPrimesWeak, "weak"},
+		{RevocationNotRevoked, "not-revoked"},
+		{RevocationInternalError, "internal-error"},
+	}
+
+	for _, d := range td {
+		assert.Equal(t, d.want, d.v.String())
+	}
+}
+
+func TestEnums_Vulnerable(t *testing.T) {
+	assert.True(t, CcsVulnerable.Vulnerable())
+	assert.False(t, CcsPossiblyVulnerable.Vulnerable())
+	assert.True(t, LuckyVulnerable.Vulnerable())
+	assert.False(t, TicketbleedSimilar.Vulnerable())
+	assert.True(t, BleichenbacherWeakOracle.Vulnerable())
+	assert.False(t, BleichenbacherInconsistent.Vulnerable())
+	assert.True(t, PoodleTLSVulnerable.Vulnerable())
+
+	assert.False(t, PaddingOracleFailed.Vulnerable())
+	assert.False(t, PaddingOracleNotVulnerable.Vulnerable())
+	assert.True(t, ZeroLengthVulnerable.Vulnerable())
+	assert.False(t, ZeroLengthVulnerable.Exploitable())
+	assert.True(t, SleepingExploitable.Exploitable())
+	assert.True(t, GoldenVulnerable.Vulnerable())
+	assert.False(t, GoldenVulnerable.Exploitable())
+	assert.True(t, GoldenExploitable.Exploitable())
+	assert.True(t, PaddingOracleExploitable.Exploitable())
+}
+
+func TestCodes_JSON(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+
+	var lr Host
+
+	require.NoError(t, json.Unmarshal(raw, &lr))
+
+	d := lr.Endpoints[0].Details
+	assert.Equal(t, RenegSecure, d.RenegSupport)
+	assert.Equal(t, ResumptionEnabled, d.SessionResumption)
+	assert.Equal(t, FSAll, d.ForwardSecrecy)
+	assert.Equal(t, SctInCert, d.HasSct)
+	assert.Equal(t, CcsNotVulnerable, d.OpenSSLCcs)
+	assert.Equal(t, PoodleTLSNotVulnerable, d.PoodleTLS)
+	assert.Equal(t, RevocationCRL|RevocationOCSP, lr.Certs[0].RevocationInfo)
+	assert.Equal(t, RevocationNotRevoked, lr.Certs[0].RevocationStatus)
+
+	// Still numbers on the wire
+	out, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"renegSupport":2`)
+	assert.Contains(t, string(out), `"poodleTls":1`)
+
+	var back EndpointDetails
+
+	require.NoError(t, json.Unmarshal(out, &back))
+	assert.Equal(t, d, back)
+}
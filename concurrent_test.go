@@ -0,0 +1,74 @@
+package ssllabs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// One Client, many goroutines, forced or not: run with -race
+func TestClient_Concurrent(t *testing.T) {
+	Before(t)
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/info") {
+			fmt.Fprint(w, `{"maxAssessments":3,"currentAssessments":0}`)
+			return
+		}
+		w.Write(fta)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(Config{
+		BaseURL: srv.URL,
+		Force:   true,
+		Poller:  NoWaitPoller{Budget: time.Minute},
+		Cache:   NewMemoryCache(10),
+	})
+	require.NoError(t, err)
+
+	before := *c
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			site := fmt.Sprintf("site%d.example.com", i%5)
+			switch i % 4 {
+			case 0:
+				grade, err := c.GetGradeWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+				assert.NoError(t, err)
+				assert.Equal(t, "A+", grade)
+			case 1:
+				_, err := c.Analyze(site, true)
+				assert.NoError(t, err)
+			case 2:
+				_, err := c.AnalyzeWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+				assert.NoError(t, err)
+			case 3:
+				_, err := c.Info()
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Nothing in the configuration has changed
+	assert.Equal(t, before, *c)
+	assert.Equal(t, DefaultRetry, c.retry.(*ExponentialBackoff).MaxRetries)
+}
@@ -0,0 +1,391 @@
+// drift.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DriftKind is what differs between the API answer and our types
+type DriftKind int
+
+const (
+	// DriftUnknown is a member matching no field
+	DriftUnknown DriftKind = iota
+	// DriftType is a member of the wrong JSON type, the field is left empty
+	DriftType
+	// DriftNumberString is a number sent as a string, it is converted
+	DriftNumberString
+	// DriftRenamed is a member matching a field only without case, or close to
+	// the name of one (e.g. namedGroupName for a field named NamedGroudName).
+	DriftRenamed
+)
+
+// String implements fmt.Stringer
+func (k DriftKind) String() string {
+	return enum("DriftKind", int(k), map[int]string{
+		0: "unknown",
+		1: "type",
+		2: "number-as-string",
+		3: "renamed",
+	})
+}
+
+// Drift is one difference, Path is like "endpoints[0].details.suites[1].protocol".
+// Field is the Go field concerned, if any, Want its JSON type and Got what was sent.
+type Drift struct {
+	Path  string
+	Kind  DriftKind
+	Field string
+	Want  string
+	Got   string
+}
+
+// String implements fmt.Stringer
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftUnknown:
+		return fmt.Sprintf("%s: unknown %s", d.Path, d.Got)
+	case DriftRenamed:
+		return fmt.Sprintf("%s: renamed %s", d.Path, d.Field)
+	}
+	return fmt.Sprintf("%s: %s, want %s got %s", d.Path, d.Kind, d.Want, d.Got)
+}
+
+// SchemaDrift is what Config.StrictDecode found in the answer to Call
+type SchemaDrift struct {
+	Call   string
+	Drifts []Drift
+}
+
+// String implements fmt.Stringer
+func (s *SchemaDrift) String() string {
+	var list []string
+
+	for _, d := range s.Drifts {
+		list = append(list, d.String())
+	}
+	return fmt.Sprintf("%s: %s", s.Call, strings.Join(list, ", "))
+}
+
+// decode is json.Unmarshal, unless StrictDecode is set.  Then raw is checked
+// against the type of v and what does not match is reported in the result,
+// numbers sent as strings are converted and members of the wrong type dropped
+// so that the rest is decoded.
+func (c *Client) decode(raw []byte, v interface{}) (*SchemaDrift, error) {
+	if !c.strict {
+		return nil, json.Unmarshal(raw, v)
+	}
+
+	var tree interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	sd := &SchemaDrift{}
+	tree = sd.walk("", tree, reflect.TypeOf(v))
+	if len(sd.Drifts) == 0 {
+		return nil, json.Unmarshal(raw, v)
+	}
+
+	fixed, err := json.Marshal(tree)
+	if err != nil {
+		return sd, err
+	}
+	if err := json.Unmarshal(fixed, v); err != nil {
+		return sd, err
+	}
+
+	// Keep what was really sent
+	switch r := v.(type) {
+	case *Host:
+		r.Raw = append(json.RawMessage(nil), raw...)
+	case *Endpoint:
+		r.Raw = append(json.RawMessage(nil), raw...)
+	}
+	return sd, nil
+}
+
+// reportDrift logs sd, if any, and gives it to Hooks.OnSchemaDrift
+func (c *Client) reportDrift(ctx context.Context, call string, sd *SchemaDrift) *SchemaDrift {
+	if sd == nil {
+		return nil
+	}
+
+	sd.Call = call
+	c.verbose("schema drift", "call", call, "drifts", sd.String())
+	c.hooks.schemaDrift(ctx, sd)
+	return sd
+}
+
+var (
+	timestampType = reflect.TypeOf(Timestamp{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+)
+
+// walk checks the value v found at path against t and returns what should be
+// decoded instead, nil if nothing.
+func (sd *SchemaDrift) walk(path string, v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// null is always fine, as is anything for raw JSON
+	if v == nil || t == rawType || t.Kind() == reflect.Interface {
+		return v
+	}
+
+	switch {
+	case t == timestampType:
+		return sd.number(path, v, true)
+	case t.Kind() == reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return sd.mismatch(path, "object", v)
+		}
+		sd.object(path, obj, t)
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		list, ok := v.([]interface{})
+		if !ok {
+			return sd.mismatch(path, "array", v)
+		}
+		for i, e := range list {
+			list[i] = sd.walk(fmt.Sprintf("%s[%d]", path, i), e, t.Elem())
+		}
+	case t.Kind() == reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return sd.mismatch(path, "object", v)
+		}
+		for _, k := range keys(obj) {
+			obj[k] = sd.walk(join(path, k), obj[k], t.Elem())
+		}
+	case t.Kind() == reflect.String:
+		if _, ok := v.(string); !ok {
+			return sd.mismatch(path, "string", v)
+		}
+	case t.Kind() == reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return sd.mismatch(path, "bool", v)
+		}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return sd.number(path, v, true)
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return sd.number(path, v, false)
+	}
+	return v
+}
+
+// mismatch records that v is not of type want, it will not be decoded
+func (sd *SchemaDrift) mismatch(path, want string, v interface{}) interface{} {
+	sd.add(Drift{Path: path, Kind: DriftType, Want: want, Got: jsonType(v)})
+	return nil
+}
+
+// number checks that v is a number, an integer if integer is set.  Numbers in
+// a string are converted.
+func (sd *SchemaDrift) number(path string, v interface{}, integer bool) interface{} {
+	want := "number"
+	if integer {
+		want = "integer"
+	}
+
+	ok := func(s string) bool {
+		if integer {
+			_, err := strconv.ParseInt(s, 10, 64)
+			return err == nil
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	}
+
+	switch n := v.(type) {
+	case json.Number:
+		if ok(n.String()) {
+			return v
+		}
+		sd.add(Drift{Path: path, Kind: DriftType, Want: want, Got: "number " + n.String()})
+	case string:
+		if s := strings.TrimSpace(n); ok(s) {
+			sd.add(Drift{Path: path, Kind: DriftNumberString, Want: want, Got: strconv.Quote(n)})
+			return json.Number(s)
+		}
+		sd.add(Drift{Path: path, Kind: DriftType, Want: want, Got: strconv.Quote(n)})
+	default:
+		sd.add(Drift{Path: path, Kind: DriftType, Want: want, Got: jsonType(v)})
+	}
+	return nil
+}
+
+// object checks every member of obj against the fields of t, those of the
+// wrong type are removed.
+func (sd *SchemaDrift) object(path string, obj map[string]interface{}, t reflect.Type) {
+	fields := structFields(t)
+
+	for _, k := range keys(obj) {
+		p := join(path, k)
+
+		f, exact := findField(fields, k)
+		switch {
+		case f == nil:
+			if g := closeField(fields, k); g != nil {
+				sd.add(Drift{Path: p, Kind: DriftRenamed, Field: g.Name, Want: g.json, Got: k})
+				continue
+			}
+			sd.add(Drift{Path: p, Kind: DriftUnknown, Got: jsonType(obj[k])})
+			continue
+		case !exact && f.tagged:
+			sd.add(Drift{Path: p, Kind: DriftRenamed, Field: f.Name, Want: f.json, Got: k})
+		}
+
+		v := obj[k]
+		if fixed := sd.walk(p, v, f.Type); fixed != nil || v == nil {
+			obj[k] = fixed
+		} else {
+			delete(obj, k)
+		}
+	}
+}
+
+// keys returns the keys of obj, sorted so that drifts are always in the same order
+func keys(obj map[string]interface{}) []string {
+	var list []string
+
+	for k := range obj {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (sd *SchemaDrift) add(d Drift) {
+	sd.Drifts = append(sd.Drifts, d)
+}
+
+// field is a struct field as encoding/json sees it
+type field struct {
+	reflect.StructField
+	json   string
+	tagged bool
+}
+
+// structFields returns the fields of t encoding/json uses
+func structFields(t reflect.Type) []field {
+	var list []field
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		ff := field{StructField: f, json: f.Name}
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				ff.json, ff.tagged = tag, true
+			}
+		}
+		list = append(list, ff)
+	}
+	return list
+}
+
+// findField finds the field for key, exact is false if only the case differs
+func findField(fields []field, key string) (*field, bool) {
+	for i := range fields {
+		if fields[i].json == key {
+			return &fields[i], true
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].json, key) {
+			return &fields[i], false
+		}
+	}
+	return nil, false
+}
+
+// closeField finds a field whose JSON or Go name is a few edits from key, one
+// for every 6 letters and no more than 2, short names being too easy to match.
+func closeField(fields []field, key string) *field {
+	limit := len(key) / 6
+	if limit > 2 {
+		limit = 2
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	key = strings.ToLower(key)
+	for i := range fields {
+		if distance(key, strings.ToLower(fields[i].json)) <= limit || distance(key, strings.ToLower(fields[i].Name)) <= limit {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// join adds key to path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonType is the JSON type of v, as decoded with UseNumber
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
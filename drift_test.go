@@ -0,0 +1,174 @@
+package ssllabs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode_Fixtures(t *testing.T) {
+	c := &Client{strict: true}
+
+	for _, f := range []struct {
+		file   string
+		v      interface{}
+		drifts []string
+	}{
+		{"testdata/multi.json", &Host{}, nil},
+		{"testdata/info.json", &Info{}, nil},
+		{"testdata/statuscodes.json", &StatusCodes{}, nil},
+		{"testdata/ssllabs-endp.json", &Endpoint{}, nil},
+		{"testdata/ssllabs-tls13.json", &Endpoint{}, nil},
+		// Saved with older versions of our types
+		{"testdata/ssllabs-full.json", &Host{}, []string{
+			"Endpoints[0].details.Bleichenbacher: renamed Bleichenbacher",
+			"Endpoints[0].details.Ticketbleed: renamed Ticketbleed",
+			"Endpoints[0].details.certChains[0].trustpaths: renamed Trustpaths",
+			"Endpoints[0].details.hstsPreloads[0].hostName: renamed HostName",
+			"Endpoints[0].details.hstsPreloads[1].hostName: renamed HostName",
+			"Endpoints[0].details.hstsPreloads[2].hostName: renamed HostName",
+			"Endpoints[0].details.hstsPreloads[3].hostName: renamed HostName",
+			"Endpoints[0].details.poodleTLS: renamed PoodleTLS",
+		}},
+	} {
+		raw, err := ioutil.ReadFile(f.file)
+		require.NoError(t, err)
+
+		sd, err := c.decode(raw, f.v)
+		assert.NoError(t, err, f.file)
+		if f.drifts == nil {
+			assert.Nil(t, sd, f.file)
+			continue
+		}
+
+		require.NotNil(t, sd, f.file)
+
+		var drifts []string
+		for _, d := range sd.Drifts {
+			drifts = append(drifts, d.String())
+		}
+		assert.Equal(t, f.drifts, drifts, f.file)
+	}
+}
+
+const drifting = `{
+  "ipAddress": "192.0.2.1",
+  "grade": "A",
+  "duration": "1234",
+  "delegation": "yes",
+  "gradeReasons": ["none"],
+  "details": {
+    "hostStartTime": "1514764800000",
+    "PrefixDelegation": true,
+    "namedGroups": {"list": [{"id": 29, "name": "x25519", "bits": 255}]},
+    "suites": [{"protocol": 772, "list": [{"id": 4865, "name": "TLS_AES_128_GCM_SHA256", "namedGroudName": "x25519", "kxStrength": "none"}]}]
+  }
+}`
+
+func TestDecode_Drift(t *testing.T) {
+	c := &Client{strict: true}
+
+	var ep Endpoint
+
+	sd, err := c.decode([]byte(drifting), &ep)
+	require.NoError(t, err)
+	require.NotNil(t, sd)
+
+	assert.Equal(t, []Drift{
+		{Path: "delegation", Kind: DriftType, Want: "integer", Got: `"yes"`},
+		{Path: "details.PrefixDelegation", Kind: DriftRenamed, Field: "PrefixDelegation", Want: "prefixDelegation", Got: "PrefixDelegation"},
+		{Path: "details.hostStartTime", Kind: DriftNumberString, Want: "integer", Got: `"1514764800000"`},
+		{Path: "details.suites[0].list[0].kxStrength", Kind: DriftType, Want: "integer", Got: `"none"`},
+		{Path: "details.suites[0].list[0].namedGroudName", Kind: DriftRenamed, Field: "NamedGroudName", Want: "namedGroupName", Got: "namedGroudName"},
+		{Path: "duration", Kind: DriftNumberString, Want: "integer", Got: `"1234"`},
+		{Path: "gradeReasons", Kind: DriftUnknown, Got: "array"},
+	}, sd.Drifts)
+
+	// The rest is decoded, numbers in strings included
+	assert.Equal(t, "A", ep.Grade)
+	assert.Equal(t, 1234, ep.Duration)
+	assert.Equal(t, 0, ep.Delegation)
+	assert.True(t, ep.Details.PrefixDelegation)
+	assert.Equal(t, int64(1514764800000), ep.Details.HostStartTime.Millis())
+	assert.Equal(t, "x25519", ep.Details.NamedGroups.List[0].Name)
+	assert.Equal(t, "TLS_AES_128_GCM_SHA256", ep.Details.Suites[0].List[0].Name)
+	assert.Contains(t, ep.Extra, "gradeReasons")
+	assert.JSONEq(t, drifting, string(ep.Raw))
+}
+
+func TestDecode_NotStrict(t *testing.T) {
+	c := &Client{}
+
+	var ep Endpoint
+
+	sd, err := c.decode([]byte(drifting), &ep)
+	assert.Error(t, err)
+	assert.Nil(t, sd)
+}
+
+func TestClient_StrictDecode(t *testing.T) {
+	Before(t)
+
+	var got []*SchemaDrift
+
+	srv := scripted(`{"host":"www.example.com","status":"READY","port":"443","endpoints":[{"ipAddress":"192.0.2.1","grade":"A","statusMessage":"Ready"}]}`)
+	defer srv.Close()
+
+	c, err := NewClient(Config{
+		BaseURL:      srv.URL,
+		Poller:       NoWaitPoller{Budget: time.Minute},
+		RetryPolicy:  NoRetry{},
+		StrictDecode: true,
+		Hooks: Hooks{
+			OnSchemaDrift: func(ctx context.Context, sd *SchemaDrift) {
+				got = append(got, sd)
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	lr, err := c.Analyze("www.example.com", false)
+	require.NoError(t, err)
+	assert.Equal(t, 443, lr.Port)
+	assert.Equal(t, "A", lr.Endpoints[0].Grade)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, got[0], lr.Drift)
+	assert.Equal(t, "analyze: port: number-as-string, want integer got \"443\"", lr.Drift.String())
+
+	// Without StrictDecode, this is an error as before
+	c, err = NewClient(Config{BaseURL: srv.URL, RetryPolicy: NoRetry{}})
+	require.NoError(t, err)
+
+	_, err = c.Analyze("www.example.com", false)
+	assert.Error(t, err)
+}
+
+func TestDrift_String(t *testing.T) {
+	assert.Equal(t, "a.b: unknown object", Drift{Path: "a.b", Kind: DriftUnknown, Got: "object"}.String())
+	assert.Equal(t, "a.b: renamed Bits", Drift{Path: "a.b", Kind: DriftRenamed, Field: "Bits"}.String())
+	assert.Equal(t, "a.b: type, want bool got string", Drift{Path: "a.b", Kind: DriftType, Want: "bool", Got: "string"}.String())
+	assert.Equal(t, "DriftKind(9)", DriftKind(9).String())
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("", ""))
+	assert.Equal(t, 3, distance("abc", ""))
+	assert.Equal(t, 1, distance("namedgroudname", "namedgroupname"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+}
+
+func TestJsonType(t *testing.T) {
+	var v interface{}
+
+	require.NoError(t, json.Unmarshal([]byte(`[null, "a", true, {}, []]`), &v))
+	for i, want := range []string{"null", "string", "bool", "object", "array"} {
+		assert.Equal(t, want, jsonType(v.([]interface{})[i]))
+	}
+	assert.Equal(t, "number", jsonType(json.Number("1")))
+}
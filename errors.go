@@ -0,0 +1,161 @@
+// errors.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// These can be used with errors.Is() to find out what went wrong with a call
+var (
+	// ErrInvalidHost is for 400, invalid parameters (generally the host itself)
+	ErrInvalidHost = errors.New("invalid host or parameters")
+	// ErrUnauthorized is for 441, unregistered or invalid email (API v4)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited is for 429, too many concurrent assessments
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInternal is for 500, something went wrong on the SSLLabs side
+	ErrInternal = errors.New("internal error")
+	// ErrServiceOverloaded is for 503 & 529, come back later
+	ErrServiceOverloaded = errors.New("service overloaded")
+	// ErrAssessmentFailed is for an assessment ending with the ERROR status
+	ErrAssessmentFailed = errors.New("assessment failed")
+)
+
+// APIError is returned for every non-200 answer from SSLLabs
+type APIError struct {
+	StatusCode int
+	Response   LabsErrorResponse
+}
+
+// newAPIError decodes the error payload if there is one
+func newAPIError(code int, body []byte) *APIError {
+	e := &APIError{StatusCode: code}
+
+	// Not all errors have a body, ignore what we can not parse
+	_ = json.Unmarshal(body, &e.Response)
+	return e
+}
+
+// Error implements the interface
+func (e *APIError) Error() string {
+	if len(e.Response.ResponseErrors) == 0 {
+		return fmt.Sprintf("status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status: %d errors: %s", e.StatusCode, e.Response.Error())
+}
+
+// Is maps the HTTP status onto our sentinel values
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidHost:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == HttpUnauthorized
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrInternal:
+		return e.StatusCode == http.StatusInternalServerError
+	case ErrServiceOverloaded:
+		return e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == HttpRetryLater
+	}
+	return false
+}
+
+// transientMessages are the (lowercase) parts of a status message meaning that
+// SSLLabs itself had a problem and that trying again later might work.
+var transientMessages = []string{
+	"internal error",
+	"capacity",
+	"too many",
+	"overloaded",
+	"try again",
+}
+
+// EndpointError is the status of one endpoint of a failed assessment
+type EndpointError struct {
+	IPAddress     string
+	StatusMessage string
+	StatusDetails string
+}
+
+// AssessmentError is returned by Analyze when the assessment ends with ERROR,
+// e.g. because the host can not be resolved or reached.
+type AssessmentError struct {
+	Host          string
+	StatusMessage string
+	Endpoints     []EndpointError
+}
+
+// newAssessmentError extracts the messages from the report
+func newAssessmentError(lr *Host) *AssessmentError {
+	e := &AssessmentError{
+		Host:          lr.Host,
+		StatusMessage: lr.StatusMessage,
+		Endpoints:     make([]EndpointError, len(lr.Endpoints)),
+	}
+
+	for i, ep := range lr.Endpoints {
+		e.Endpoints[i] = EndpointError{
+			IPAddress:     ep.IPAddress,
+			StatusMessage: ep.StatusMessage,
+			StatusDetails: ep.StatusDetails,
+		}
+	}
+	return e
+}
+
+// Error implements the interface
+func (e *AssessmentError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "assessment of %s failed: %s", e.Host, e.StatusMessage)
+	for _, ep := range e.Endpoints {
+		if ep.StatusMessage == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, ", %s: %s", ep.IPAddress, ep.StatusMessage)
+		if ep.StatusDetails != "" {
+			fmt.Fprintf(&sb, " (%s)", ep.StatusDetails)
+		}
+	}
+	return sb.String()
+}
+
+// Is makes errors.Is(err, ErrAssessmentFailed) work
+func (e *AssessmentError) Is(target error) bool {
+	return target == ErrAssessmentFailed
+}
+
+// Permanent is true when trying again will not help (unknown host, unable to
+// connect, no TLS, etc.), only problems on the SSLLabs side are transient.
+func (e *AssessmentError) Permanent() bool {
+	msgs := []string{e.StatusMessage}
+	for _, ep := range e.Endpoints {
+		msgs = append(msgs, ep.StatusMessage)
+	}
+
+	for _, m := range msgs {
+		m = strings.ToLower(m)
+		for _, t := range transientMessages {
+			if strings.Contains(m, t) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsPermanent tells whether err is an assessment failure not worth retrying
+func IsPermanent(err error) bool {
+	var ae *AssessmentError
+
+	return errors.As(err, &ae) && ae.Permanent()
+}
@@ -0,0 +1,197 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_Error(t *testing.T) {
+	e := &APIError{StatusCode: 503}
+
+	assert.Equal(t, "status: 503", e.Error())
+}
+
+func TestAPIError_Error2(t *testing.T) {
+	e := newAPIError(400, []byte(`{"errors":[{"field":"host","message":"qlue.validation.mandatory"}]}`))
+
+	require.Len(t, e.Response.ResponseErrors, 1)
+	assert.Equal(t, "host", e.Response.ResponseErrors[0].Field)
+	assert.Equal(t, "status: 400 errors: {\"errors\":[{\"Field\":\"host\",\"Message\":\"qlue.validation.mandatory\"}]}", e.Error())
+}
+
+func TestAPIError_Is(t *testing.T) {
+	td := []struct {
+		code int
+		err  error
+	}{
+		{http.StatusBadRequest, ErrInvalidHost},
+		{HttpUnauthorized, ErrUnauthorized},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrInternal},
+		{http.StatusServiceUnavailable, ErrServiceOverloaded},
+		{HttpRetryLater, ErrServiceOverloaded},
+	}
+
+	for _, d := range td {
+		e := errors.Wrap(newAPIError(d.code, nil), "wrapped")
+		assert.True(t, errors.Is(e, d.err), "%d", d.code)
+		assert.False(t, errors.Is(e, errUnknown), "%d", d.code)
+	}
+}
+
+// errUnknown is never matched
+var errUnknown = errors.New("unknown")
+
+func TestClient_CallAPIRateLimited(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(429).
+		BodyString(`{"errors":[{"message":"Concurrent assessment limit reached (25/25)"}]}`)
+
+	c, err := NewClient(Config{RetryPolicy: NoRetry{}})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	info, err := c.Info()
+	require.Error(t, err)
+	assert.Empty(t, info)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var ae *APIError
+
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, 429, ae.StatusCode)
+	require.Len(t, ae.Response.ResponseErrors, 1)
+	assert.Equal(t, "Concurrent assessment limit reached (25/25)", ae.Response.ResponseErrors[0].Message)
+}
+
+func TestClient_CallAPIInvalidHost(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	fte, err := ioutil.ReadFile("testdata/emptyanalyze.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fte)
+
+	gock.New(baseURL).
+		Get("/getEndpointData").
+		Reply(400).
+		BodyString(string(fte))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.GetEndpointDataContext(context.Background(), "invalid")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidHost))
+	assert.False(t, errors.Is(err, ErrRateLimited))
+}
+
+func TestClient_CallAPIOverloaded(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	gock.New(baseURL).
+		Get("/getStatusCodes").
+		Reply(529)
+
+	c, err := NewClient(Config{RetryPolicy: NoRetry{}})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.GetStatusCodes()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrServiceOverloaded))
+}
+
+func TestAssessmentError(t *testing.T) {
+	lr := &Host{
+		Host:          "invalid.example.com",
+		Status:        "ERROR",
+		StatusMessage: "Unable to resolve domain name",
+	}
+
+	e := newAssessmentError(lr)
+	assert.Equal(t, "assessment of invalid.example.com failed: Unable to resolve domain name", e.Error())
+	assert.True(t, errors.Is(errors.Wrap(e, "wrapped"), ErrAssessmentFailed))
+	assert.False(t, errors.Is(e, ErrInternal))
+	assert.True(t, e.Permanent())
+	assert.True(t, IsPermanent(errors.Wrap(e, "wrapped")))
+}
+
+func TestAssessmentError_Endpoints(t *testing.T) {
+	lr := &Host{
+		Host:          "example.com",
+		Status:        "ERROR",
+		StatusMessage: "Assessment failed",
+		Endpoints: []Endpoint{
+			{IPAddress: "192.0.2.1", StatusMessage: "Unable to connect to the server", StatusDetails: "TESTING_PROTOCOL_INTOLERANCE_399"},
+			{IPAddress: "192.0.2.2", StatusMessage: "Internal error"},
+		},
+	}
+
+	e := newAssessmentError(lr)
+	require.Len(t, e.Endpoints, 2)
+	assert.Equal(t, "TESTING_PROTOCOL_INTOLERANCE_399", e.Endpoints[0].StatusDetails)
+	assert.Equal(t, "assessment of example.com failed: Assessment failed, 192.0.2.1: Unable to connect to the server (TESTING_PROTOCOL_INTOLERANCE_399), 192.0.2.2: Internal error", e.Error())
+	assert.False(t, e.Permanent())
+	assert.False(t, IsPermanent(e))
+	assert.True(t, isTransient(e))
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.False(t, IsPermanent(nil))
+	assert.False(t, IsPermanent(newAPIError(400, nil)))
+	assert.False(t, IsPermanent(&AssessmentError{StatusMessage: "Running at full capacity"}))
+}
+
+func TestClient_AnalyzeError(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Reply(200).
+		BodyString(`{"host":"invalid.example.com","status":"ERROR","statusMessage":"Unable to resolve domain name"}`)
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	start := time.Now()
+	an, err := c.Analyze("invalid.example.com", false)
+	require.Error(t, err)
+	assert.Empty(t, an)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, gock.IsDone())
+
+	var ae *AssessmentError
+
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, "Unable to resolve domain name", ae.StatusMessage)
+	assert.True(t, ae.Permanent())
+}
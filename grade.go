@@ -0,0 +1,185 @@
+// grade.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GradePolicy is how GetGrade combines the grades of a site with several
+// endpoints (IPv4 & IPv6 addresses).
+type GradePolicy int
+
+const (
+	// GradeWorst keeps the worst grade, the default
+	GradeWorst GradePolicy = iota
+	// GradeBest keeps the best grade
+	GradeBest
+	// GradeMajority keeps the most common grade, the worst one in case of a tie
+	GradeMajority
+)
+
+// gradeOrder is from best to worst, T (trust issues) and M (name mismatch) are
+// below F as they mean the certificate can not be used at all.
+var gradeOrder = []string{"A+", "A", "A-", "B", "C", "D", "E", "F", "T", "M"}
+
+// String implements fmt.Stringer
+func (p GradePolicy) String() string {
+	switch p {
+	case GradeWorst:
+		return "worst"
+	case GradeBest:
+		return "best"
+	case GradeMajority:
+		return "majority"
+	}
+	return fmt.Sprintf("GradePolicy(%d)", int(p))
+}
+
+// Aggregate returns the grade chosen by the policy, "" if there is none
+func (p GradePolicy) Aggregate(grades []string) string {
+	if len(grades) == 0 {
+		return ""
+	}
+
+	res := grades[0]
+	switch p {
+	case GradeBest:
+		for _, g := range grades[1:] {
+			if worse(res, g) {
+				res = g
+			}
+		}
+	case GradeMajority:
+		count := map[string]int{}
+		for _, g := range grades {
+			count[g]++
+		}
+		for g, n := range count {
+			if n > count[res] || (n == count[res] && worse(g, res)) {
+				res = g
+			}
+		}
+	default:
+		for _, g := range grades[1:] {
+			if worse(g, res) {
+				res = g
+			}
+		}
+	}
+	return res
+}
+
+// worse is true if a is a worse grade than b, unknown grades are sorted by name
+func worse(a, b string) bool {
+	ra, rb := gradeRank(a), gradeRank(b)
+	if ra == rb {
+		return a > b
+	}
+	return ra > rb
+}
+
+// gradeRank is the position of g in gradeOrder, unknown grades being the worst
+func gradeRank(g string) int {
+	for i, o := range gradeOrder {
+		if g == o {
+			return i
+		}
+	}
+	return len(gradeOrder)
+}
+
+// GradeError lists the endpoints without a grade.  It comes with the grades of
+// the other endpoints, if any.
+type GradeError struct {
+	Host      string
+	Endpoints []EndpointError
+}
+
+// Error implements the interface
+func (e *GradeError) Error() string {
+	var msgs []string
+
+	for _, ep := range e.Endpoints {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", ep.IPAddress, ep.StatusMessage))
+	}
+	return fmt.Sprintf("no grade for %s: %s", e.Host, strings.Join(msgs, ", "))
+}
+
+// GetGrades returns the grade of every endpoint of site, indexed by IP address.
+// When some endpoints have no grade, the others are returned along with a *GradeError.
+func (c *Client) GetGrades(site string) (map[string]string, error) {
+	return c.GetGradesWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+}
+
+// GetGradesWithOptions is GetGrades with typed options, Config.Force still applies
+func (c *Client) GetGradesWithOptions(ctx context.Context, site string, o AnalyzeOptions) (map[string]string, error) {
+	if site == "" {
+		return map[string]string{}, errors.New("empty site")
+	}
+
+	lr, err := c.AnalyzeWithOptions(ctx, site, c.forced(o))
+	if err != nil {
+		return map[string]string{}, errors.Wrap(err, "GetGrades")
+	}
+	return gradesOf(lr)
+}
+
+// gradesOf extracts the grades from the report, err is a *GradeError if any
+// endpoint is not ready.
+func gradesOf(lr *Host) (map[string]string, error) {
+	grades := map[string]string{}
+
+	if len(lr.Endpoints) == 0 {
+		return grades, errors.New("no endpoint")
+	}
+
+	ge := &GradeError{Host: lr.Host}
+	for _, e := range lr.Endpoints {
+		if e.StatusMessage != "Ready" {
+			ge.Endpoints = append(ge.Endpoints, EndpointError{
+				IPAddress:     e.IPAddress,
+				StatusMessage: e.StatusMessage,
+				StatusDetails: e.StatusDetails,
+			})
+			continue
+		}
+		grades[e.IPAddress] = e.Grade
+	}
+
+	if len(ge.Endpoints) != 0 {
+		return grades, ge
+	}
+	return grades, nil
+}
+
+// gradeOf combines the grades of all endpoints with the client policy, it
+// fails only if no endpoint has a grade.
+func (c *Client) gradeOf(lr *Host) (string, error) {
+	grades, err := gradesOf(lr)
+	if err != nil && len(grades) != 0 {
+		c.verbose("partial grade", "host", lr.Host, "error", err)
+	}
+	return c.policy.Grade(lr)
+}
+
+// Grade combines the grades of all endpoints of a report, like GetGrade does
+// without calling SSLLabs again.  It fails only if no endpoint has a grade.
+func (p GradePolicy) Grade(lr *Host) (string, error) {
+	grades, err := gradesOf(lr)
+	if len(grades) == 0 {
+		return "Z", err
+	}
+
+	var list []string
+	for _, g := range grades {
+		list = append(list, g)
+	}
+	return p.Aggregate(list), nil
+}
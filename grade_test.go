@@ -0,0 +1,207 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGradePolicy_String(t *testing.T) {
+	assert.Equal(t, "worst", GradeWorst.String())
+	assert.Equal(t, "best", GradeBest.String())
+	assert.Equal(t, "majority", GradeMajority.String())
+	assert.Equal(t, "GradePolicy(42)", GradePolicy(42).String())
+}
+
+func TestGradePolicy_Aggregate(t *testing.T) {
+	td := []struct {
+		p      GradePolicy
+		grades []string
+		res    string
+	}{
+		{GradeWorst, nil, ""},
+		{GradeWorst, []string{"A"}, "A"},
+		{GradeWorst, []string{"A+", "B", "A-"}, "B"},
+		{GradeWorst, []string{"A+", "T", "F"}, "T"},
+		{GradeWorst, []string{"A", "M", "T"}, "M"},
+		{GradeBest, []string{"B", "A+", "A-"}, "A+"},
+		{GradeBest, []string{"F", "T"}, "F"},
+		{GradeMajority, []string{"A+", "B", "A+"}, "A+"},
+		{GradeMajority, []string{"A+", "B", "B", "A+"}, "B"},
+		{GradeMajority, []string{"C"}, "C"},
+	}
+
+	for _, d := range td {
+		assert.Equal(t, d.res, d.p.Aggregate(d.grades), "%v %v", d.p, d.grades)
+	}
+}
+
+func TestGradePolicy_Grade(t *testing.T) {
+	lr := &Host{
+		Host: "ssllabs.com",
+		Endpoints: []Endpoint{
+			{IPAddress: "192.0.2.1", Grade: "A+", StatusMessage: "Ready"},
+			{IPAddress: "192.0.2.2", Grade: "B", StatusMessage: "Ready"},
+			{IPAddress: "192.0.2.3", StatusMessage: "Unable to connect to the server"},
+		},
+	}
+
+	g, err := GradeWorst.Grade(lr)
+	require.NoError(t, err)
+	assert.Equal(t, "B", g)
+
+	g, err = GradeBest.Grade(lr)
+	require.NoError(t, err)
+	assert.Equal(t, "A+", g)
+
+	g, err = GradeWorst.Grade(&Host{Host: "ssllabs.com"})
+	assert.Error(t, err)
+	assert.Equal(t, "Z", g)
+}
+
+func TestGradesOf(t *testing.T) {
+	grades, err := gradesOf(&Host{Host: "foo"})
+	assert.Error(t, err)
+	assert.Empty(t, grades)
+
+	lr := &Host{
+		Host: "example.com",
+		Endpoints: []Endpoint{
+			{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A"},
+			{IPAddress: "192.0.2.2", StatusMessage: "Unable to connect to the server"},
+		},
+	}
+
+	grades, err = gradesOf(lr)
+	require.Error(t, err)
+	assert.Equal(t, map[string]string{"192.0.2.1": "A"}, grades)
+
+	var ge *GradeError
+
+	require.True(t, errors.As(err, &ge))
+	require.Len(t, ge.Endpoints, 1)
+	assert.Equal(t, "192.0.2.2", ge.Endpoints[0].IPAddress)
+	assert.Equal(t, "no grade for example.com: 192.0.2.2: Unable to connect to the server", err.Error())
+}
+
+func TestNewClient_BadGradePolicy(t *testing.T) {
+	c, err := NewClient(Config{GradePolicy: GradePolicy(3)})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestClient_GetGrades(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	ftm, err := ioutil.ReadFile("testdata/multi.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftm)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "example.com").
+		Reply(200).
+		BodyString(string(ftm))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	grades, err := c.GetGrades("example.com")
+	require.Error(t, err)
+	assert.Equal(t, map[string]string{
+		"192.0.2.1":   "A+",
+		"192.0.2.2":   "B",
+		"2001:db8::1": "A+",
+	}, grades)
+
+	var ge *GradeError
+
+	require.True(t, errors.As(err, &ge))
+	require.Len(t, ge.Endpoints, 1)
+	assert.Equal(t, "2001:db8::2", ge.Endpoints[0].IPAddress)
+	assert.Equal(t, "TESTING_PROTOCOL_INTOLERANCE_399", ge.Endpoints[0].StatusDetails)
+}
+
+func TestClient_GetGradesEmpty(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	grades, err := c.GetGradesWithOptions(context.Background(), "", DefaultAnalyzeOptions())
+	assert.Error(t, err)
+	assert.Empty(t, grades)
+}
+
+func TestClient_GetGradePolicy(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	ftm, err := ioutil.ReadFile("testdata/multi.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftm)
+
+	td := []struct {
+		p     GradePolicy
+		grade string
+	}{
+		{GradeWorst, "B"},
+		{GradeBest, "A+"},
+		{GradeMajority, "A+"},
+	}
+
+	for _, d := range td {
+		gock.New(baseURL).
+			Get("/analyze").
+			MatchParam("host", "example.com").
+			Reply(200).
+			BodyString(string(ftm))
+
+		c, err := NewClient(Config{GradePolicy: d.p})
+		require.NoError(t, err)
+
+		gock.InterceptClient(c.client)
+
+		grade, err := c.GetGrade("example.com")
+		require.NoError(t, err, "%v", d.p)
+		assert.Equal(t, d.grade, grade, "%v", d.p)
+
+		gock.RestoreClient(c.client)
+	}
+}
+
+// One bad endpoint does not spoil the report
+func TestClient_GetDetailedReportPartial(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	ftm, err := ioutil.ReadFile("testdata/multi.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftm)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParam("host", "example.com").
+		Reply(200).
+		BodyString(string(ftm))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	report, err := c.GetDetailedReport("example.com")
+	require.NoError(t, err)
+	assert.Len(t, report.Endpoints, 4)
+}
@@ -0,0 +1,96 @@
+// hooks.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+	"time"
+)
+
+// RequestEvent is sent before every HTTP request to the API, including retries
+type RequestEvent struct {
+	Call   string
+	Method string
+	URL    string
+	Retry  int
+}
+
+// ResponseEvent is sent after every HTTP request, StatusCode is 0 and Err set
+// if nothing came back.
+type ResponseEvent struct {
+	Call       string
+	Method     string
+	URL        string
+	Retry      int
+	StatusCode int
+	Bytes      int
+	Duration   time.Duration
+	Err        error
+}
+
+// StatusEvent is sent when the status of an assessment changes, From is empty
+// for the first poll.
+type StatusEvent struct {
+	Host    string
+	From    string
+	To      string
+	Message string
+	Poll    int
+}
+
+// Hooks are called synchronously from the goroutine doing the call, they should
+// not block.  Any of them can be nil.
+type Hooks struct {
+	// OnRequest is called before each HTTP request
+	OnRequest func(ctx context.Context, ev RequestEvent)
+	// OnResponse is called after each HTTP request
+	OnResponse func(ctx context.Context, ev ResponseEvent)
+	// OnStatusChange is called by Analyze when the host goes from DNS to IN_PROGRESS, READY, etc.
+	OnStatusChange func(ctx context.Context, ev StatusEvent)
+	// OnEndpointReady is called by Analyze once for every endpoint becoming ready
+	OnEndpointReady func(ctx context.Context, host string, ep Endpoint)
+	// OnSchemaDrift is called with Config.StrictDecode when an answer does not match our types
+	OnSchemaDrift func(ctx context.Context, sd *SchemaDrift)
+}
+
+func (h Hooks) request(ctx context.Context, ev RequestEvent) {
+	if h.OnRequest != nil {
+		h.OnRequest(ctx, ev)
+	}
+}
+
+func (h Hooks) response(ctx context.Context, ev ResponseEvent) {
+	if h.OnResponse != nil {
+		h.OnResponse(ctx, ev)
+	}
+}
+
+func (h Hooks) statusChange(ctx context.Context, ev StatusEvent) {
+	if h.OnStatusChange != nil {
+		h.OnStatusChange(ctx, ev)
+	}
+}
+
+func (h Hooks) schemaDrift(ctx context.Context, sd *SchemaDrift) {
+	if h.OnSchemaDrift != nil {
+		h.OnSchemaDrift(ctx, sd)
+	}
+}
+
+// endpointsReady calls OnEndpointReady for the endpoints of lr not in seen,
+// which is updated.
+func (h Hooks) endpointsReady(ctx context.Context, lr *Host, seen map[string]bool) {
+	if h.OnEndpointReady == nil {
+		return
+	}
+
+	for _, ep := range lr.Endpoints {
+		if ep.StatusMessage != "Ready" || seen[ep.IPAddress] {
+			continue
+		}
+		seen[ep.IPAddress] = true
+		h.OnEndpointReady(ctx, lr.Host, ep)
+	}
+}
@@ -0,0 +1,134 @@
+package ssllabs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// polls is what the server answers to the successive analyze calls
+var polls = []string{
+	`{"host":"www.example.com","status":"DNS","statusMessage":"Resolving domain names"}`,
+	`{"host":"www.example.com","status":"IN_PROGRESS","endpoints":[{"ipAddress":"192.0.2.1","statusMessage":"Ready","grade":"A"},{"ipAddress":"192.0.2.2","statusMessage":"In progress"}]}`,
+	`{"host":"www.example.com","status":"IN_PROGRESS","endpoints":[{"ipAddress":"192.0.2.1","statusMessage":"Ready","grade":"A"},{"ipAddress":"192.0.2.2","statusMessage":"In progress"}]}`,
+	`{"host":"www.example.com","status":"READY","statusMessage":"Ready","endpoints":[{"ipAddress":"192.0.2.1","statusMessage":"Ready","grade":"A"},{"ipAddress":"192.0.2.2","statusMessage":"Ready","grade":"B"}]}`,
+}
+
+// scripted answers the analyze calls with answers, the last one being repeated
+func scripted(answers ...string) *httptest.Server {
+	var (
+		mu sync.Mutex
+		n  int
+	)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/info") {
+			fmt.Fprint(w, `{"maxAssessments":3,"currentAssessments":0}`)
+			return
+		}
+		fmt.Fprint(w, answers[n])
+		if n < len(answers)-1 {
+			n++
+		}
+	}))
+}
+
+func TestHooks(t *testing.T) {
+	Before(t)
+
+	var (
+		reqs     []RequestEvent
+		resps    []ResponseEvent
+		statuses []StatusEvent
+		ready    []string
+	)
+
+	srv := scripted(polls...)
+	defer srv.Close()
+
+	c, err := NewClient(Config{
+		BaseURL:     srv.URL,
+		Poller:      NoWaitPoller{Budget: time.Minute},
+		RetryPolicy: NoRetry{},
+		Hooks: Hooks{
+			OnRequest: func(ctx context.Context, ev RequestEvent) {
+				reqs = append(reqs, ev)
+			},
+			OnResponse: func(ctx context.Context, ev ResponseEvent) {
+				resps = append(resps, ev)
+			},
+			OnStatusChange: func(ctx context.Context, ev StatusEvent) {
+				statuses = append(statuses, ev)
+			},
+			OnEndpointReady: func(ctx context.Context, host string, ep Endpoint) {
+				assert.Equal(t, "www.example.com", host)
+				ready = append(ready, ep.IPAddress)
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	lr, err := c.Analyze("www.example.com", false)
+	require.NoError(t, err)
+	assert.Equal(t, "READY", lr.Status)
+
+	require.Len(t, reqs, 4)
+	require.Len(t, resps, 4)
+	for i, ev := range resps {
+		assert.Equal(t, "analyze", reqs[i].Call)
+		assert.Equal(t, "GET", reqs[i].Method)
+		assert.Contains(t, reqs[i].URL, "host=www.example.com")
+
+		assert.Equal(t, reqs[i].URL, ev.URL)
+		assert.Equal(t, http.StatusOK, ev.StatusCode)
+		assert.Equal(t, len(polls[i]), ev.Bytes)
+		assert.NoError(t, ev.Err)
+	}
+
+	assert.Equal(t, []StatusEvent{
+		{Host: "www.example.com", From: "", To: "DNS", Message: "Resolving domain names", Poll: 0},
+		{Host: "www.example.com", From: "DNS", To: "IN_PROGRESS", Poll: 1},
+		{Host: "www.example.com", From: "IN_PROGRESS", To: "READY", Message: "Ready", Poll: 3},
+	}, statuses)
+	assert.Equal(t, []string{"192.0.2.1", "192.0.2.2"}, ready)
+}
+
+func TestHooks_ResponseError(t *testing.T) {
+	Before(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	var resps []ResponseEvent
+
+	c, err := NewClient(Config{
+		BaseURL:     url,
+		RetryPolicy: NoRetry{},
+		Hooks: Hooks{
+			OnResponse: func(ctx context.Context, ev ResponseEvent) {
+				resps = append(resps, ev)
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = c.Info()
+	require.Error(t, err)
+
+	require.Len(t, resps, 1)
+	assert.Equal(t, "info", resps[0].Call)
+	assert.Equal(t, 0, resps[0].StatusCode)
+	assert.Error(t, resps[0].Err)
+}
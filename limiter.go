@@ -0,0 +1,142 @@
+// limiter.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Headers sent by SSLLabs with every answer
+	hdrMaxAssessments     = "X-Max-Assessments"
+	hdrCurrentAssessments = "X-Current-Assessments"
+)
+
+// limiter tracks how many assessments we are allowed to start, it is fed by
+// Info() and by the headers of every answer.  The headers do not have the
+// cool-off, so known (max is set) is not enough to start assessments, we also
+// need seeded (Info() was called).
+type limiter struct {
+	mu      sync.Mutex
+	known   bool
+	seeded  bool
+	max     int
+	current int
+	coolOff time.Duration
+	last    time.Time
+	changed chan struct{}
+}
+
+func newLimiter() *limiter {
+	return &limiter{changed: make(chan struct{})}
+}
+
+// notify wakes up everyone waiting in acquire, must be called with mu held
+func (l *limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// isKnown is true once we have heard from the server
+func (l *limiter) isKnown() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.known
+}
+
+// isSeeded is true once Info() has been called
+func (l *limiter) isSeeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seeded
+}
+
+// seed uses the values from Info()
+func (l *limiter) seed(inf *Info) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.known = true
+	l.seeded = true
+	l.max = inf.MaxAssessments
+	l.current = inf.CurrentAssessments
+	l.coolOff = time.Duration(inf.NewAssessmentCoolOff) * time.Millisecond
+	l.notify()
+}
+
+// update uses the X-*-Assessments headers if present
+func (l *limiter) update(h http.Header) {
+	max, err1 := strconv.Atoi(h.Get(hdrMaxAssessments))
+	cur, err2 := strconv.Atoi(h.Get(hdrCurrentAssessments))
+	if err1 != nil && err2 != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err1 == nil {
+		l.max = max
+		l.known = true
+	}
+	if err2 == nil {
+		l.current = cur
+	}
+	l.notify()
+}
+
+// acquire blocks until we can start a new assessment
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.known && l.max <= 0 {
+			cur, max := l.current, l.max
+			l.mu.Unlock()
+			return errors.Wrapf(ErrRateLimited, "max assessment reached: %d/%d", cur, max)
+		}
+
+		wait := l.coolOff - time.Since(l.last)
+		if l.current < l.max && wait <= 0 {
+			l.current++
+			l.last = time.Now()
+			l.mu.Unlock()
+			return nil
+		}
+
+		// Full, wait for a release or an update
+		if wait <= 0 {
+			wait = l.coolOff
+			if wait <= 0 {
+				wait = time.Second
+			}
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "waiting for an assessment slot")
+		case <-changed:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// release gives back the slot taken by acquire
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current > 0 {
+		l.current--
+	}
+	l.notify()
+}
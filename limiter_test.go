@@ -0,0 +1,206 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Seed(t *testing.T) {
+	l := newLimiter()
+	assert.False(t, l.isKnown())
+
+	l.seed(&Info{MaxAssessments: 25, CurrentAssessments: 3, NewAssessmentCoolOff: 1000})
+	assert.True(t, l.isKnown())
+	assert.Equal(t, 25, l.max)
+	assert.Equal(t, 3, l.current)
+	assert.Equal(t, time.Second, l.coolOff)
+}
+
+func TestLimiter_Update(t *testing.T) {
+	l := newLimiter()
+
+	l.update(http.Header{})
+	assert.False(t, l.isKnown())
+
+	h := http.Header{}
+	h.Set(hdrMaxAssessments, "10")
+	h.Set(hdrCurrentAssessments, "4")
+
+	l.update(h)
+	assert.True(t, l.isKnown())
+	assert.Equal(t, 10, l.max)
+	assert.Equal(t, 4, l.current)
+
+	// Headers do not have the cool-off, Info is still needed
+	assert.False(t, l.isSeeded())
+
+	l.seed(&Info{MaxAssessments: 10, CurrentAssessments: 4, NewAssessmentCoolOff: 1000})
+	assert.True(t, l.isSeeded())
+	assert.Equal(t, time.Second, l.coolOff)
+}
+
+func TestLimiter_AcquireLast(t *testing.T) {
+	l := newLimiter()
+	l.seed(&Info{MaxAssessments: 2, CurrentAssessments: 1})
+
+	// current == max - 1 means we still have one slot
+	require.NoError(t, l.acquire(context.Background()))
+	assert.Equal(t, 2, l.current)
+}
+
+func TestLimiter_AcquireNone(t *testing.T) {
+	l := newLimiter()
+	l.seed(&Info{MaxAssessments: 0, CurrentAssessments: 2})
+
+	err := l.acquire(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+}
+
+func TestLimiter_AcquireBlocks(t *testing.T) {
+	l := newLimiter()
+	l.seed(&Info{MaxAssessments: 1})
+
+	require.NoError(t, l.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := l.acquire(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestLimiter_AcquireRelease(t *testing.T) {
+	l := newLimiter()
+	l.seed(&Info{MaxAssessments: 1})
+
+	require.NoError(t, l.acquire(context.Background()))
+
+	done := make(chan error)
+	go func() {
+		done <- l.acquire(context.Background())
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	l.release()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("acquire not woken up by release")
+	}
+}
+
+func TestLimiter_AcquireCoolOff(t *testing.T) {
+	l := newLimiter()
+	l.seed(&Info{MaxAssessments: 10, NewAssessmentCoolOff: 100})
+
+	start := time.Now()
+	require.NoError(t, l.acquire(context.Background()))
+	require.NoError(t, l.acquire(context.Background()))
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+}
+
+// Info must be called only once per client, not once per host
+func TestClient_AnalyzeForceInfoOnce(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	ftc, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftc)
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURL).
+		Get("/info").
+		Times(1).
+		Reply(200).
+		BodyString(string(fti))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Times(4).
+		Reply(200).
+		SetHeader(hdrMaxAssessments, "25").
+		SetHeader(hdrCurrentAssessments, "1").
+		BodyString(string(ftc))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.Analyze("ssllabs.com", true)
+	require.NoError(t, err)
+
+	_, err = c.Analyze("www.ssllabs.com", true)
+	require.NoError(t, err)
+
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, 25, c.limiter.max)
+}
+
+// Headers seen before the first assessment must not prevent calling Info
+func TestClient_AnalyzeForceHeadersFirst(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	fts, err := ioutil.ReadFile("testdata/statuscodes.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fts)
+
+	ftc, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftc)
+
+	gock.New(baseURL).
+		Get("/getStatusCodes").
+		Reply(200).
+		SetHeader(hdrMaxAssessments, "25").
+		SetHeader(hdrCurrentAssessments, "0").
+		BodyString(string(fts))
+
+	gock.New(baseURL).
+		Get("/info").
+		Times(1).
+		Reply(200).
+		BodyString(`{"maxAssessments":25,"currentAssessments":0,"newAssessmentCoolOff":1000}`)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Times(2).
+		Reply(200).
+		BodyString(string(ftc))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.GetStatusCodes()
+	require.NoError(t, err)
+	assert.True(t, c.limiter.isKnown())
+
+	_, err = c.Analyze("ssllabs.com", true)
+	require.NoError(t, err)
+
+	assert.True(t, gock.IsDone())
+	assert.Equal(t, time.Second, c.limiter.coolOff)
+}
@@ -0,0 +1,101 @@
+// logger.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces secrets in logs
+const Redacted = "[REDACTED]"
+
+// Logger receives structured records, kv being key/value pairs like log/slog.
+// It must be safe for concurrent use.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+}
+
+// stdLogger is the default, using the standard log package with Config.Log as
+// level (1: verbose, 2: debug).
+type stdLogger struct {
+	level int
+}
+
+// Debug implements Logger
+func (l stdLogger) Debug(msg string, kv ...interface{}) {
+	if l.level >= 2 {
+		log.Print(format(msg, kv))
+	}
+}
+
+// Info implements Logger
+func (l stdLogger) Info(msg string, kv ...interface{}) {
+	if l.level >= 1 {
+		log.Print(format(msg, kv))
+	}
+}
+
+// format gives "msg key=value key=value"
+func format(msg string, kv []interface{}) string {
+	var sb strings.Builder
+
+	sb.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			fmt.Fprintf(&sb, " %v=%v", kv[i], kv[i+1])
+		} else {
+			fmt.Fprintf(&sb, " %v", kv[i])
+		}
+	}
+	return sb.String()
+}
+
+// slogLogger sends everything to a *slog.Logger
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger adapts a *slog.Logger, nil means slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l: l}
+}
+
+// Debug implements Logger
+func (s slogLogger) Debug(msg string, kv ...interface{}) {
+	s.l.Debug(msg, messages(kv)...)
+}
+
+// Info implements Logger
+func (s slogLogger) Info(msg string, kv ...interface{}) {
+	s.l.Info(msg, messages(kv)...)
+}
+
+// messages replaces errors by their message, slog would print the stack
+// trace of the pkg/errors ones with %+v.
+func messages(kv []interface{}) []interface{} {
+	out := make([]interface{}, len(kv))
+	for i, v := range kv {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// redact hides secrets but still tells whether they are set
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
@@ -0,0 +1,92 @@
+package ssllabs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "foo", format("foo", nil))
+	assert.Equal(t, "foo a=1 b=bar", format("foo", []interface{}{"a", 1, "b", "bar"}))
+	assert.Equal(t, "foo a=1 b", format("foo", []interface{}{"a", 1, "b"}))
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "", redact(""))
+	assert.Equal(t, Redacted, redact("secret"))
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	stdLogger{level: 0}.Info("info")
+	stdLogger{level: 1}.Debug("debug")
+	assert.Empty(t, buf.String())
+
+	stdLogger{level: 1}.Info("info", "k", "v")
+	assert.Contains(t, buf.String(), "info k=v")
+
+	stdLogger{level: 2}.Debug("debug", "k", 2)
+	assert.Contains(t, buf.String(), "debug k=2")
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l.Debug("debug", "k", "v")
+	l.Info("info", "n", 1)
+
+	assert.Contains(t, buf.String(), "level=DEBUG msg=debug k=v")
+	assert.Contains(t, buf.String(), "level=INFO msg=info n=1")
+
+	assert.NotNil(t, NewSlogLogger(nil))
+}
+
+// Structured records, without the email
+func TestClient_Logger(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	var buf bytes.Buffer
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURLv4).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	c, err := NewClient(Config{APIVersion: 4, Email: "john@example.com", Logger: l})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.Info()
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "msg=\"client created\"")
+	assert.Contains(t, out, "email="+Redacted)
+	assert.Contains(t, out, "msg=request method=GET url="+baseURLv4+"/info")
+	assert.Contains(t, out, "msg=response")
+	assert.Contains(t, out, "status=200")
+	assert.NotContains(t, out, "john@example.com")
+}
@@ -0,0 +1,144 @@
+// options.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AllMode is the "all" parameter of analyze
+type AllMode int
+
+const (
+	// AllDefault does not send the parameter, only basic endpoint info is returned
+	AllDefault AllMode = iota
+	// AllOn returns full information for every endpoint, even during the assessment
+	AllOn
+	// AllDone returns full information only once the assessment is finished
+	AllDone
+)
+
+// String implements fmt.Stringer, it is the value sent to the API
+func (a AllMode) String() string {
+	switch a {
+	case AllDefault:
+		return ""
+	case AllOn:
+		return "on"
+	case AllDone:
+		return "done"
+	}
+	return fmt.Sprintf("AllMode(%d)", int(a))
+}
+
+// AnalyzeOptions are the parameters of the analyze call, the zero value is not
+// what Analyze uses by default, see DefaultAnalyzeOptions.
+type AnalyzeOptions struct {
+	// Publish the results on the public SSLLabs boards
+	Publish bool
+	// StartNew ignores the cache and starts a new assessment, incompatible with FromCache
+	StartNew bool
+	// FromCache returns a cached assessment if there is one
+	FromCache bool
+	// MaxAge is the maximum age of a cached report, in whole hours
+	MaxAge time.Duration
+	// All is how much details we want
+	All AllMode
+	// IgnoreMismatch proceeds even if the certificate does not match the host
+	IgnoreMismatch bool
+	// Progress, if set, is called on every poll, it is not sent to SSLLabs
+	Progress ProgressFunc
+}
+
+// DefaultAnalyzeOptions returns the same defaults as Analyze
+func DefaultAnalyzeOptions() AnalyzeOptions {
+	return AnalyzeOptions{
+		FromCache:      true,
+		MaxAge:         24 * time.Hour,
+		IgnoreMismatch: true,
+	}
+}
+
+// Validate checks the options are consistent
+func (o AnalyzeOptions) Validate() error {
+	if o.StartNew && o.FromCache {
+		return errors.New("StartNew and FromCache are mutually exclusive")
+	}
+	if o.MaxAge < 0 {
+		return fmt.Errorf("negative MaxAge %v", o.MaxAge)
+	}
+	if o.MaxAge%time.Hour != 0 {
+		return fmt.Errorf("MaxAge %v is not in whole hours", o.MaxAge)
+	}
+	if o.All < AllDefault || o.All > AllDone {
+		return fmt.Errorf("invalid All value %d", int(o.All))
+	}
+	return nil
+}
+
+// Encode returns the query parameters, except host
+func (o AnalyzeOptions) Encode() map[string]string {
+	opts := map[string]string{
+		"publish":        onOff(o.Publish),
+		"fromCache":      onOff(o.FromCache),
+		"ignoreMismatch": onOff(o.IgnoreMismatch),
+	}
+
+	if o.StartNew {
+		opts["startNew"] = "on"
+	}
+	if o.MaxAge > 0 {
+		opts["maxAge"] = strconv.Itoa(int(o.MaxAge / time.Hour))
+	}
+	if o.All != AllDefault {
+		opts["all"] = o.All.String()
+	}
+	return opts
+}
+
+// EndpointOptions are the parameters of the getEndpointData call
+type EndpointOptions struct {
+	// IPAddress of the endpoint, sent as "s"
+	IPAddress string
+	// FromCache returns the cached data even if an assessment is in progress
+	FromCache bool
+}
+
+// DefaultEndpointOptions returns the same defaults as GetEndpointData
+func DefaultEndpointOptions() EndpointOptions {
+	return EndpointOptions{FromCache: true}
+}
+
+// Validate checks the options are consistent
+func (o EndpointOptions) Validate() error {
+	if o.IPAddress != "" && net.ParseIP(o.IPAddress) == nil {
+		return fmt.Errorf("invalid IP address %q", o.IPAddress)
+	}
+	return nil
+}
+
+// Encode returns the query parameters, except host
+func (o EndpointOptions) Encode() map[string]string {
+	opts := map[string]string{
+		"fromCache": onOff(o.FromCache),
+	}
+
+	if o.IPAddress != "" {
+		opts["s"] = o.IPAddress
+	}
+	return opts
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
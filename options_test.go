@@ -0,0 +1,249 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllMode_String(t *testing.T) {
+	assert.Equal(t, "", AllDefault.String())
+	assert.Equal(t, "on", AllOn.String())
+	assert.Equal(t, "done", AllDone.String())
+	assert.Equal(t, "AllMode(42)", AllMode(42).String())
+}
+
+func TestDefaultAnalyzeOptions(t *testing.T) {
+	o := DefaultAnalyzeOptions()
+	require.NoError(t, o.Validate())
+
+	// Same as the historical defaults of Analyze
+	ot := map[string]string{
+		"publish":        "off",
+		"maxAge":         "24",
+		"fromCache":      "on",
+		"ignoreMismatch": "on",
+	}
+	assert.EqualValues(t, ot, o.Encode())
+}
+
+func TestAnalyzeOptions_Encode(t *testing.T) {
+	o := AnalyzeOptions{
+		Publish:  true,
+		StartNew: true,
+		All:      AllDone,
+	}
+
+	ot := map[string]string{
+		"publish":        "on",
+		"startNew":       "on",
+		"fromCache":      "off",
+		"ignoreMismatch": "off",
+		"all":            "done",
+	}
+	assert.EqualValues(t, ot, o.Encode())
+}
+
+func TestAnalyzeOptions_Validate(t *testing.T) {
+	td := []AnalyzeOptions{
+		{StartNew: true, FromCache: true},
+		{MaxAge: -time.Hour},
+		{MaxAge: 90 * time.Minute},
+		{All: AllMode(3)},
+		{All: AllMode(-1)},
+	}
+
+	for _, o := range td {
+		assert.Error(t, o.Validate(), "%#v", o)
+	}
+
+	assert.NoError(t, AnalyzeOptions{StartNew: true, MaxAge: 2 * time.Hour, All: AllOn}.Validate())
+}
+
+func TestEndpointOptions(t *testing.T) {
+	o := DefaultEndpointOptions()
+	require.NoError(t, o.Validate())
+	assert.EqualValues(t, map[string]string{"fromCache": "on"}, o.Encode())
+
+	o = EndpointOptions{IPAddress: "64.41.200.100"}
+	require.NoError(t, o.Validate())
+	assert.EqualValues(t, map[string]string{"fromCache": "off", "s": "64.41.200.100"}, o.Encode())
+
+	o = EndpointOptions{IPAddress: "ssllabs.com"}
+	assert.Error(t, o.Validate())
+}
+
+func TestClient_AnalyzeWithOptionsInvalid(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	an, err := c.AnalyzeWithOptions(context.Background(), "ssllabs.com", AnalyzeOptions{StartNew: true, FromCache: true})
+	require.Error(t, err)
+	assert.Empty(t, an)
+
+	an, err = c.AnalyzeWithOptions(context.Background(), "", DefaultAnalyzeOptions())
+	require.Error(t, err)
+	assert.Empty(t, an)
+}
+
+// startNew is sent only once, then we poll without it
+func TestClient_AnalyzeWithOptionsStartNew(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	ftc, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, ftc)
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParams(map[string]string{"host": site, "startNew": "on", "fromCache": "off", "all": "done"}).
+		Reply(200).
+		BodyString(`{"host":"ssllabs.com","status":"DNS"}`)
+
+	// Do not pollute gock.DefaultMatcher
+	m := gock.NewMatcher()
+	m.Add(func(req *http.Request, _ *gock.Request) (bool, error) {
+		return req.URL.Query().Get("startNew") == "", nil
+	})
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParams(map[string]string{"host": site, "fromCache": "off", "all": "done"}).
+		SetMatcher(m).
+		Reply(200).
+		BodyString(string(ftc))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	o := AnalyzeOptions{StartNew: true, All: AllDone, IgnoreMismatch: true}
+
+	an, err := c.AnalyzeWithOptions(context.Background(), site, o)
+	require.NoError(t, err)
+	assert.Equal(t, "READY", an.Status)
+	assert.True(t, gock.IsDone())
+}
+
+func TestClient_GetGradeWithOptions(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParams(map[string]string{
+			"host":           site,
+			"publish":        "off",
+			"maxAge":         "24",
+			"fromCache":      "on",
+			"ignoreMismatch": "on",
+		}).
+		Reply(200).
+		BodyString(string(fta))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	grade, err := c.GetGradeWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "A+", grade)
+}
+
+func TestClient_GetDetailedReportWithOptions(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "www.ssllabs.com"
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		MatchParams(map[string]string{"host": site, "all": "done", "fromCache": "on"}).
+		Reply(200).
+		BodyString(string(fta))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	r, err := c.GetDetailedReportWithOptions(context.Background(), site, DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.NotEmpty(t, r.Endpoints[0].Details.Protocols)
+}
+
+func TestClient_GetEndpointDataWithOptions(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs-endp.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/getEndpointData").
+		MatchParams(map[string]string{"host": site, "s": "64.41.200.100", "fromCache": "on"}).
+		Reply(200).
+		BodyString(string(fta))
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	o := EndpointOptions{IPAddress: "64.41.200.100", FromCache: true}
+
+	data, err := c.GetEndpointDataWithOptions(context.Background(), site, o)
+	require.NoError(t, err)
+	assert.Equal(t, "64.41.200.100", data.IPAddress)
+}
+
+func TestClient_ForcedOptions(t *testing.T) {
+	c, err := NewClient(Config{Force: true})
+	require.NoError(t, err)
+
+	o := c.forced(DefaultAnalyzeOptions())
+	assert.True(t, o.StartNew)
+	assert.False(t, o.FromCache)
+	assert.NoError(t, o.Validate())
+}
@@ -0,0 +1,108 @@
+// poll.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"time"
+)
+
+const (
+	// DefaultPollPending is the delay between polls until the assessment is IN_PROGRESS
+	DefaultPollPending = 10 * time.Second
+
+	// DefaultPollInProgress is the shortest delay once the assessment is IN_PROGRESS
+	DefaultPollInProgress = 5 * time.Second
+
+	// DefaultPollMax is the longest delay between two polls
+	DefaultPollMax = 30 * time.Second
+
+	// DefaultPollBudget is the total time we wait for an assessment
+	DefaultPollBudget = 15 * time.Minute
+)
+
+// Poller decides when Analyze asks again for the status of an assessment.
+//
+// lr is the last answer and elapsed the time spent since the first call,
+// returning false means we give up.  A Poller is shared by all the calls of a
+// Client so it must be safe for concurrent use.
+type Poller interface {
+	Next(lr *Host, elapsed time.Duration) (time.Duration, bool)
+}
+
+// AdaptivePoller polls slowly while SSLLabs is resolving or queueing the
+// host then, once IN_PROGRESS, uses the largest endpoint Eta bounded by
+// InProgress and Max.  The whole assessment must fit within Budget.  Fields
+// left to 0 use the DefaultPoll* values.
+type AdaptivePoller struct {
+	Pending    time.Duration
+	InProgress time.Duration
+	Max        time.Duration
+	Budget     time.Duration
+}
+
+// NewAdaptivePoller returns the default poller
+func NewAdaptivePoller() *AdaptivePoller {
+	return &AdaptivePoller{
+		Pending:    DefaultPollPending,
+		InProgress: DefaultPollInProgress,
+		Max:        DefaultPollMax,
+		Budget:     DefaultPollBudget,
+	}
+}
+
+// Next implements Poller
+func (p *AdaptivePoller) Next(lr *Host, elapsed time.Duration) (time.Duration, bool) {
+	budget := orDefault(p.Budget, DefaultPollBudget)
+	if elapsed >= budget {
+		return 0, false
+	}
+
+	wait := orDefault(p.Pending, DefaultPollPending)
+	if lr.Status == "IN_PROGRESS" {
+		wait = time.Duration(maxEta(lr)) * time.Second
+		if lo := orDefault(p.InProgress, DefaultPollInProgress); wait < lo {
+			wait = lo
+		}
+		if hi := orDefault(p.Max, DefaultPollMax); wait > hi {
+			wait = hi
+		}
+	}
+
+	// Last poll right at the end of the budget
+	if elapsed+wait > budget {
+		wait = budget - elapsed
+	}
+	return wait, true
+}
+
+// NoWaitPoller polls again at once until Budget (DefaultPollBudget if 0) is
+// spent.  It is for replays and fake servers, never use it with SSLLabs.
+type NoWaitPoller struct {
+	Budget time.Duration
+}
+
+// Next implements Poller
+func (p NoWaitPoller) Next(lr *Host, elapsed time.Duration) (time.Duration, bool) {
+	return 0, elapsed < orDefault(p.Budget, DefaultPollBudget)
+}
+
+// orDefault returns def if d is not set
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// maxEta returns the largest Eta of all endpoints, in seconds
+func maxEta(lr *Host) int {
+	eta := 0
+	for _, e := range lr.Endpoints {
+		if e.Eta > eta {
+			eta = e.Eta
+		}
+	}
+	return eta
+}
@@ -0,0 +1,132 @@
+package ssllabs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAdaptivePoller(t *testing.T) {
+	p := NewAdaptivePoller()
+
+	assert.Equal(t, DefaultPollPending, p.Pending)
+	assert.Equal(t, DefaultPollInProgress, p.InProgress)
+	assert.Equal(t, DefaultPollMax, p.Max)
+	assert.Equal(t, DefaultPollBudget, p.Budget)
+}
+
+func TestAdaptivePoller_NextPending(t *testing.T) {
+	p := NewAdaptivePoller()
+
+	for _, st := range []string{"", "DNS", "PENDING"} {
+		d, ok := p.Next(&Host{Status: st}, 0)
+		assert.True(t, ok)
+		assert.Equal(t, DefaultPollPending, d, st)
+	}
+}
+
+func TestAdaptivePoller_NextInProgress(t *testing.T) {
+	p := NewAdaptivePoller()
+
+	td := []struct {
+		etas []int
+		wait time.Duration
+	}{
+		{nil, DefaultPollInProgress},
+		{[]int{1}, DefaultPollInProgress},
+		{[]int{-1, 12, 7}, 12 * time.Second},
+		{[]int{600}, DefaultPollMax},
+	}
+
+	for _, d := range td {
+		lr := &Host{Status: "IN_PROGRESS"}
+		for _, eta := range d.etas {
+			lr.Endpoints = append(lr.Endpoints, Endpoint{Eta: eta})
+		}
+
+		wait, ok := p.Next(lr, time.Minute)
+		assert.True(t, ok)
+		assert.Equal(t, d.wait, wait, "%v", d.etas)
+	}
+}
+
+func TestAdaptivePoller_NextBudget(t *testing.T) {
+	p := NewAdaptivePoller()
+
+	wait, ok := p.Next(&Host{Status: "DNS"}, p.Budget-time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	_, ok = p.Next(&Host{Status: "DNS"}, p.Budget)
+	assert.False(t, ok)
+}
+
+func TestAdaptivePoller_Zero(t *testing.T) {
+	p := &AdaptivePoller{}
+
+	wait, ok := p.Next(&Host{Status: "DNS"}, 0)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultPollPending, wait)
+
+	wait, ok = p.Next(&Host{Status: "IN_PROGRESS"}, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultPollInProgress, wait)
+
+	wait, ok = p.Next(&Host{Status: "IN_PROGRESS", Endpoints: []Endpoint{{Eta: 600}}}, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultPollMax, wait)
+
+	_, ok = p.Next(&Host{Status: "DNS"}, DefaultPollBudget)
+	assert.False(t, ok)
+
+	// Only the budget set, the delays are still the default ones
+	p = &AdaptivePoller{Budget: 30 * time.Minute}
+	wait, ok = p.Next(&Host{Status: "DNS"}, 0)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultPollPending, wait)
+}
+
+func TestNoWaitPoller(t *testing.T) {
+	wait, ok := NoWaitPoller{}.Next(&Host{Status: "DNS"}, time.Minute)
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+
+	_, ok = NoWaitPoller{}.Next(&Host{Status: "DNS"}, DefaultPollBudget)
+	assert.False(t, ok)
+
+	_, ok = NoWaitPoller{Budget: time.Second}.Next(&Host{Status: "DNS"}, time.Second)
+	assert.False(t, ok)
+}
+
+func TestClient_AnalyzeBudget(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Persist().
+		Reply(200).
+		BodyString(`{"host":"ssllabs.com","status":"DNS"}`)
+
+	p := &AdaptivePoller{Pending: 10 * time.Millisecond, Budget: 50 * time.Millisecond}
+	c, err := NewClient(Config{Poller: p})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	an, err := c.AnalyzeContext(context.Background(), "ssllabs.com", false)
+	require.Error(t, err)
+	assert.Empty(t, an)
+	assert.Contains(t, err.Error(), "polling budget exceeded")
+}
+
+func TestMaxEta(t *testing.T) {
+	assert.Equal(t, 0, maxEta(&Host{}))
+	assert.Equal(t, 9, maxEta(&Host{Endpoints: []Endpoint{{Eta: 3}, {Eta: 9}, {Eta: -1}}}))
+}
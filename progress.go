@@ -0,0 +1,84 @@
+// progress.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"time"
+)
+
+// ProgressEvent is sent on every poll done by Analyze
+type ProgressEvent struct {
+	Host          string
+	Status        string
+	StatusMessage string
+	Poll          int
+	Time          time.Time
+	Endpoints     []EndpointProgress
+}
+
+// EndpointProgress is the state of one endpoint during the assessment
+type EndpointProgress struct {
+	IPAddress            string
+	ServerName           string
+	StatusMessage        string
+	StatusDetails        string
+	StatusDetailsMessage string
+	Grade                string
+	Progress             int
+	Eta                  int
+}
+
+// ProgressFunc receives the events, it is called from the polling goroutine so
+// it should not block.
+type ProgressFunc func(ProgressEvent)
+
+// Progress is the average progress of all endpoints, in percent.  Endpoints
+// not started yet report -1 and count as 0.
+func (p ProgressEvent) Progress() int {
+	if len(p.Endpoints) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, e := range p.Endpoints {
+		if e.Progress > 0 {
+			total += e.Progress
+		}
+	}
+	return total / len(p.Endpoints)
+}
+
+// newProgressEvent extracts what is interesting from the current report
+func newProgressEvent(lr *Host, poll int) ProgressEvent {
+	ev := ProgressEvent{
+		Host:          lr.Host,
+		Status:        lr.Status,
+		StatusMessage: lr.StatusMessage,
+		Poll:          poll,
+		Time:          time.Now(),
+		Endpoints:     make([]EndpointProgress, len(lr.Endpoints)),
+	}
+
+	for i, e := range lr.Endpoints {
+		ev.Endpoints[i] = EndpointProgress{
+			IPAddress:            e.IPAddress,
+			ServerName:           e.ServerName,
+			StatusMessage:        e.StatusMessage,
+			StatusDetails:        e.StatusDetails,
+			StatusDetailsMessage: e.StatusDetailsMessage,
+			Grade:                e.Grade,
+			Progress:             e.Progress,
+			Eta:                  e.Eta,
+		}
+	}
+	return ev
+}
+
+// reportProgress sends the event if someone is listening
+func reportProgress(fn ProgressFunc, lr *Host, poll int) {
+	if fn != nil {
+		fn(newProgressEvent(lr, poll))
+	}
+}
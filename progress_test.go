@@ -0,0 +1,93 @@
+package ssllabs
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressEvent_Progress(t *testing.T) {
+	ev := ProgressEvent{}
+	assert.Equal(t, 0, ev.Progress())
+
+	ev.Endpoints = []EndpointProgress{{Progress: 100}, {Progress: -1}}
+	assert.Equal(t, 50, ev.Progress())
+}
+
+func TestNewProgressEvent(t *testing.T) {
+	lr := &Host{
+		Host:   "ssllabs.com",
+		Status: "IN_PROGRESS",
+		Endpoints: []Endpoint{
+			{
+				IPAddress:            "64.41.200.100",
+				StatusMessage:        "In progress",
+				StatusDetails:        "TESTING_HEARTBLEED",
+				StatusDetailsMessage: "Testing Heartbleed",
+				Progress:             42,
+				Eta:                  30,
+			},
+		},
+	}
+
+	ev := newProgressEvent(lr, 3)
+	assert.Equal(t, "ssllabs.com", ev.Host)
+	assert.Equal(t, "IN_PROGRESS", ev.Status)
+	assert.Equal(t, 3, ev.Poll)
+	require.Len(t, ev.Endpoints, 1)
+	assert.Equal(t, "TESTING_HEARTBLEED", ev.Endpoints[0].StatusDetails)
+	assert.Equal(t, 42, ev.Endpoints[0].Progress)
+	assert.Equal(t, 30, ev.Endpoints[0].Eta)
+	assert.False(t, ev.Time.IsZero())
+}
+
+func TestClient_AnalyzeProgress(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Reply(200).
+		BodyString(`{"host":"ssllabs.com","status":"IN_PROGRESS","endpoints":[{"ipAddress":"64.41.200.100","progress":12,"eta":20}]}`)
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Reply(200).
+		BodyString(string(fta))
+
+	c, err := NewClient(Config{Poller: NoWaitPoller{Budget: time.Minute}})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	var evs []ProgressEvent
+
+	opts := DefaultAnalyzeOptions()
+	opts.Progress = func(ev ProgressEvent) {
+		evs = append(evs, ev)
+	}
+
+	_, err = c.AnalyzeWithOptions(context.Background(), site, opts)
+	require.NoError(t, err)
+
+	require.Len(t, evs, 2)
+	assert.Equal(t, "IN_PROGRESS", evs[0].Status)
+	assert.Equal(t, 12, evs[0].Progress())
+	assert.Equal(t, 0, evs[0].Poll)
+	assert.Equal(t, "READY", evs[1].Status)
+	assert.Equal(t, 100, evs[1].Progress())
+	assert.Equal(t, 1, evs[1].Poll)
+}
@@ -0,0 +1,181 @@
+// raw.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+/*
+SSLLabs adds fields before we do, so Host, Endpoint and EndpointDetails keep
+the keys they do not know in Extra and put them back when marshalled.  Host
+and Endpoint also keep the JSON they were decoded from in Raw.
+*/
+
+// fieldNames caches the JSON names of the fields of each type
+var fieldNames sync.Map
+
+// jsonNames returns the names encoding/json uses for the fields of t
+func jsonNames(t reflect.Type) []string {
+	if names, ok := fieldNames.Load(t); ok {
+		return names.([]string)
+	}
+
+	var names []string
+	for _, f := range structFields(t) {
+		names = append(names, f.json)
+	}
+	fieldNames.Store(t, names)
+	return names
+}
+
+// extraKeys returns the members of the object in b that match no field of t,
+// ignoring case like encoding/json does.  It is nil if there are none or b is
+// not an object.
+func extraKeys(b []byte, t reflect.Type) map[string]json.RawMessage {
+	var all map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil
+	}
+
+	names := jsonNames(t)
+	for k := range all {
+		for _, n := range names {
+			if strings.EqualFold(k, n) {
+				delete(all, k)
+				break
+			}
+		}
+	}
+
+	if len(all) == 0 {
+		return nil
+	}
+	return all
+}
+
+// withExtra adds extra to the object in b, our own fields win
+func withExtra(b []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return b, nil
+	}
+
+	var all map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if _, ok := all[k]; !ok {
+			all[k] = v
+		}
+	}
+	return json.Marshal(all)
+}
+
+// isNull is true for the JSON null, which leaves the value untouched
+func isNull(b []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(b), []byte("null"))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling Raw and Extra
+func (lr *Host) UnmarshalJSON(b []byte) error {
+	type host Host
+
+	if isNull(b) {
+		return nil
+	}
+
+	var h host
+
+	err := json.Unmarshal(b, &h)
+	h.Raw = append(json.RawMessage(nil), b...)
+	h.Extra = extraKeys(b, reflect.TypeOf(h))
+	*lr = Host(h)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, Extra is put back
+func (lr Host) MarshalJSON() ([]byte, error) {
+	type host Host
+
+	b, err := json.Marshal(host(lr))
+	if err != nil {
+		return nil, err
+	}
+	return withExtra(b, lr.Extra)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling Raw and Extra
+func (ep *Endpoint) UnmarshalJSON(b []byte) error {
+	type endpoint Endpoint
+
+	if isNull(b) {
+		return nil
+	}
+
+	var e endpoint
+
+	err := json.Unmarshal(b, &e)
+	e.Raw = append(json.RawMessage(nil), b...)
+	e.Extra = extraKeys(b, reflect.TypeOf(e))
+	*ep = Endpoint(e)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, Extra is put back
+func (ep Endpoint) MarshalJSON() ([]byte, error) {
+	type endpoint Endpoint
+
+	b, err := json.Marshal(endpoint(ep))
+	if err != nil {
+		return nil, err
+	}
+	return withExtra(b, ep.Extra)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, filling Extra
+func (d *EndpointDetails) UnmarshalJSON(b []byte) error {
+	type details EndpointDetails
+
+	if isNull(b) {
+		return nil
+	}
+
+	var ed details
+
+	err := json.Unmarshal(b, &ed)
+	ed.Extra = extraKeys(b, reflect.TypeOf(ed))
+
+	// Reports marshalled before the tag was fixed have goldenPoodle
+	if old, ok := ed.Extra["goldenPoodle"]; ok && ed.GoldenPoodle == PaddingOracleUnknown {
+		if err == nil {
+			err = json.Unmarshal(old, &ed.GoldenPoodle)
+		}
+		delete(ed.Extra, "goldenPoodle")
+		if len(ed.Extra) == 0 {
+			ed.Extra = nil
+		}
+	}
+
+	*d = EndpointDetails(ed)
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, Extra is put back
+func (d EndpointDetails) MarshalJSON() ([]byte, error) {
+	type details EndpointDetails
+
+	b, err := json.Marshal(details(d))
+	if err != nil {
+		return nil, err
+	}
+	return withExtra(b, d.Extra)
+}
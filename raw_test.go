@@ -0,0 +1,140 @@
+package ssllabs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const future = `{
+  "host": "ssllabs.com",
+  "port": 443,
+  "status": "READY",
+  "quantumSafe": true,
+  "endpoints": [
+    {
+      "ipAddress": "64.41.200.100",
+      "grade": "A+",
+      "gradeReasons": ["none"],
+      "details": {"renegSupport": 2, "postQuantum": {"kem": "X25519MLKEM768"}}
+    }
+  ]
+}`
+
+func TestHost_Extra(t *testing.T) {
+	var lr Host
+
+	require.NoError(t, json.Unmarshal([]byte(future), &lr))
+
+	assert.Equal(t, "ssllabs.com", lr.Host)
+	assert.JSONEq(t, future, string(lr.Raw))
+	assert.Equal(t, map[string]json.RawMessage{"quantumSafe": json.RawMessage("true")}, lr.Extra)
+
+	require.Len(t, lr.Endpoints, 1)
+	ep := lr.Endpoints[0]
+	assert.Equal(t, "A+", ep.Grade)
+	assert.Equal(t, map[string]json.RawMessage{"gradeReasons": json.RawMessage(`["none"]`)}, ep.Extra)
+	assert.Equal(t, RenegSecure, ep.Details.RenegSupport)
+	assert.Equal(t, map[string]json.RawMessage{"postQuantum": json.RawMessage(`{"kem": "X25519MLKEM768"}`)}, ep.Details.Extra)
+
+	out, err := json.Marshal(lr)
+	require.NoError(t, err)
+	lossless(t, []byte(future), out)
+}
+
+func TestHost_NoExtra(t *testing.T) {
+	var lr Host
+
+	require.NoError(t, json.Unmarshal([]byte(`{"host":"ssllabs.com","Status":"READY"}`), &lr))
+	assert.Nil(t, lr.Extra)
+	assert.Equal(t, "READY", lr.Status)
+}
+
+func TestHost_Null(t *testing.T) {
+	lr := Host{Host: "ssllabs.com"}
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &lr))
+	assert.Equal(t, "ssllabs.com", lr.Host)
+	assert.Nil(t, lr.Raw)
+}
+
+func TestHost_BadType(t *testing.T) {
+	var lr Host
+
+	err := json.Unmarshal([]byte(`{"host":"ssllabs.com","port":"443","bogus":1}`), &lr)
+	require.Error(t, err)
+
+	// Like encoding/json, what could be decoded is there
+	assert.Equal(t, "ssllabs.com", lr.Host)
+	assert.Contains(t, lr.Extra, "bogus")
+}
+
+func TestLossless_Fixtures(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/ssllabs-endp.json")
+	require.NoError(t, err)
+
+	var ep Endpoint
+
+	require.NoError(t, json.Unmarshal(raw, &ep))
+	out, err := json.Marshal(ep)
+	require.NoError(t, err)
+	lossless(t, raw, out)
+
+	raw, err = ioutil.ReadFile("testdata/multi.json")
+	require.NoError(t, err)
+
+	var lr Host
+
+	require.NoError(t, json.Unmarshal(raw, &lr))
+	out, err = json.Marshal(&lr)
+	require.NoError(t, err)
+	lossless(t, raw, out)
+}
+
+// lossless checks that every member of in is in out with the same value, keys
+// being compared without case like encoding/json does.
+func lossless(t *testing.T, in, out []byte) {
+	t.Helper()
+
+	var a, b interface{}
+
+	require.NoError(t, json.Unmarshal(in, &a))
+	require.NoError(t, json.Unmarshal(out, &b))
+	contains(t, "", a, b)
+}
+
+func contains(t *testing.T, path string, a, b interface{}) {
+	t.Helper()
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		require.True(t, ok, "%s: not an object", path)
+
+		for k, v := range av {
+			found := false
+			for bk, w := range bv {
+				if strings.EqualFold(k, bk) {
+					contains(t, path+"."+k, v, w)
+					found = true
+					break
+				}
+			}
+			assert.True(t, found, "%s.%s: missing", path, k)
+		}
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		require.True(t, ok, "%s: not an array", path)
+		require.Len(t, bv, len(av), path)
+
+		for i := range av {
+			contains(t, path+"[]", av[i], bv[i])
+		}
+	default:
+		assert.Equal(t, a, b, path)
+	}
+}
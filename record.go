@@ -0,0 +1,226 @@
+// record.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// tapeFiles matches the interactions of a cassette, numbered from 0000
+const tapeFiles = "[0-9][0-9][0-9][0-9].json"
+
+// RecorderMode tells Recorder what to do with the cassette
+type RecorderMode int
+
+const (
+	// ModeReplay serves the recorded answers, nothing goes to the network
+	ModeReplay RecorderMode = iota
+	// ModeRecord sends the requests and writes every answer in the cassette
+	ModeRecord
+)
+
+// String implements fmt.Stringer
+func (m RecorderMode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	}
+	return fmt.Sprintf("RecorderMode(%d)", int(m))
+}
+
+// Interaction is one request/response pair, stored as a JSON file in the cassette.
+// URL is the path with its query sorted, the host is not kept.
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper recording conversations with SSLLabs into
+// a cassette directory, or replaying them.
+//
+// The same request can be answered differently over time (the analyze polls),
+// so each one is replayed in the recorded order, the last answer being repeated
+// if needed.  Request headers are not recorded as they carry the email.
+type Recorder struct {
+	mode RecorderMode
+	dir  string
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	count int
+	tape  map[string][]Interaction
+	seen  map[string]int
+}
+
+// NewRecorder opens the cassette in dir.  next is the transport used in record
+// mode, http.DefaultTransport if nil.
+func NewRecorder(dir string, mode RecorderMode, next http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{
+		mode: mode,
+		dir:  dir,
+		next: next,
+		tape: map[string][]Interaction{},
+		seen: map[string]int{},
+	}
+
+	switch mode {
+	case ModeRecord:
+		if r.next == nil {
+			r.next = http.DefaultTransport
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "recorder")
+		}
+		// Start a new cassette
+		old, _ := filepath.Glob(filepath.Join(dir, tapeFiles))
+		for _, f := range old {
+			os.Remove(f)
+		}
+	case ModeReplay:
+		if err := r.load(); err != nil {
+			return nil, errors.Wrap(err, "recorder")
+		}
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %d", int(mode))
+	}
+	return r, nil
+}
+
+// load reads the whole cassette, files are named after the recording order
+func (r *Recorder) load() error {
+	files, err := filepath.Glob(filepath.Join(r.dir, tapeFiles))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("empty cassette %s", r.dir)
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		raw, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		var in Interaction
+
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return errors.Wrapf(err, "bad interaction %s", f)
+		}
+		key := in.Method + " " + in.URL
+		r.tape[key] = append(r.tape[key], in)
+	}
+	return nil
+}
+
+// requestURL is the path and the sorted query of req
+func requestURL(req *http.Request) string {
+	q := req.URL.Query().Encode()
+	if q == "" {
+		return req.URL.Path
+	}
+	return req.URL.Path + "?" + q
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+// record does the real request and saves the answer
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "recorder/read")
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	in := Interaction{
+		Method: req.Method,
+		URL:    requestURL(req),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   string(body),
+	}
+
+	raw, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "recorder/marshal")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file := filepath.Join(r.dir, fmt.Sprintf("%04d.json", r.count))
+	r.count++
+	if err := ioutil.WriteFile(file, raw, 0644); err != nil {
+		return nil, errors.Wrap(err, "recorder/write")
+	}
+	return resp, nil
+}
+
+// replay finds the next answer for req
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + requestURL(req)
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	list, ok := r.tape[key]
+	n := r.seen[key]
+	r.seen[key]++
+	r.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("recorder: no interaction for %s", key)
+	}
+	if n >= len(list) {
+		n = len(list) - 1
+	}
+	in := list[n]
+
+	header := in.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(in.Body))),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}, nil
+}
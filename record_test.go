@@ -0,0 +1,114 @@
+package ssllabs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderMode_String(t *testing.T) {
+	assert.Equal(t, "replay", ModeReplay.String())
+	assert.Equal(t, "record", ModeRecord.String())
+	assert.Equal(t, "RecorderMode(3)", RecorderMode(3).String())
+}
+
+func TestNewRecorder_Errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewRecorder(dir, ModeReplay, nil)
+	assert.Error(t, err)
+
+	_, err = NewRecorder(dir, RecorderMode(3), nil)
+	assert.Error(t, err)
+}
+
+func TestRequestURL(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://api.ssllabs.com/api/v3/analyze?publish=off&host=ssllabs.com&all=done", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v3/analyze?all=done&host=ssllabs.com&publish=off", requestURL(req))
+
+	req, err = http.NewRequest("GET", "https://api.ssllabs.com/api/v3/info", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v3/info", requestURL(req))
+}
+
+// Record a polling sequence then replay it without any server
+func TestRecorder_RecordReplay(t *testing.T) {
+	Before(t)
+
+	fta, err := ioutil.ReadFile("testdata/ssllabs.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fta)
+
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(hdrMaxAssessments, "25")
+		polls++
+		if polls < 3 {
+			fmt.Fprintf(w, `{"host":"ssllabs.com","status":"IN_PROGRESS","endpoints":[{"ipAddress":"64.41.200.100","progress":%d}]}`, polls*30)
+			return
+		}
+		w.Write(fta)
+	}))
+
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	poller := NoWaitPoller{}
+
+	c, err := NewClient(Config{BaseURL: srv.URL, Cassette: dir, CassetteMode: ModeRecord, Poller: poller})
+	require.NoError(t, err)
+
+	rec, err := c.Analyze("ssllabs.com", false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, polls)
+	srv.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, tapeFiles))
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// Nothing listening anymore
+	c, err = NewClient(Config{BaseURL: srv.URL, Cassette: dir})
+	require.NoError(t, err)
+	assert.Equal(t, NoWaitPoller{}, c.poller)
+
+	// Twice, always the same sequence
+	for i := 0; i < 2; i++ {
+		rp, err := NewRecorder(dir, ModeReplay, nil)
+		require.NoError(t, err)
+		c.client.Transport = rp
+
+		lr, err := c.Analyze("ssllabs.com", false)
+		require.NoError(t, err)
+		assert.EqualValues(t, rec, lr)
+		assert.Equal(t, 3, rp.seen["GET /analyze?fromCache=on&host=ssllabs.com&ignoreMismatch=on&maxAge=24&publish=off"])
+	}
+}
+
+func TestRecorder_ReplayUnknown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "0000.json"), []byte(`{"method":"GET","url":"/info","status":200,"body":"{}"}`), 0644))
+
+	c, err := NewClient(Config{BaseURL: "http://localhost", Cassette: dir, RetryPolicy: NoRetry{}})
+	require.NoError(t, err)
+
+	_, err = c.GetStatusCodes()
+	assert.Error(t, err)
+
+	_, err = c.Info()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,148 @@
+// retry.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBackoffMin is the first delay between two attempts
+	DefaultBackoffMin = 1 * time.Second
+
+	// DefaultBackoffMax is the longest we wait between two attempts
+	DefaultBackoffMax = 60 * time.Second
+)
+
+// RetryPolicy decides whether a failed API call is tried again and after how long.
+//
+// retry starts at 0 for the first failure, resp is nil when the request did not
+// go through (network error) and err is what the call returned.  A RetryPolicy
+// is shared by all the calls of a Client so it must be safe for concurrent use.
+type RetryPolicy interface {
+	Backoff(retry int, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// NoRetry never retries anything
+type NoRetry struct{}
+
+// Backoff implements RetryPolicy
+func (NoRetry) Backoff(retry int, resp *http.Response, err error) (time.Duration, bool) {
+	return 0, false
+}
+
+// ExponentialBackoff retries transient errors (429, 503, 529, network errors and
+// assessments failing on the SSLLabs side) waiting a random duration between half
+// and all of Min * 2^retry, capped at Max.
+// A Retry-After header sent by the server always takes precedence.
+type ExponentialBackoff struct {
+	Min        time.Duration
+	Max        time.Duration
+	MaxRetries int
+}
+
+// NewExponentialBackoff returns the default policy
+func NewExponentialBackoff() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		Min:        DefaultBackoffMin,
+		Max:        DefaultBackoffMax,
+		MaxRetries: DefaultRetry,
+	}
+}
+
+// Backoff implements RetryPolicy
+func (b *ExponentialBackoff) Backoff(retry int, resp *http.Response, err error) (time.Duration, bool) {
+	if retry >= b.MaxRetries || !isTransient(err) {
+		return 0, false
+	}
+
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d, true
+		}
+	}
+
+	d := float64(b.Min) * math.Pow(2, float64(retry))
+	if d > float64(b.Max) || d <= 0 {
+		d = float64(b.Max)
+	}
+	return time.Duration(d/2 + jitter(d/2)), true
+}
+
+// isTransient tells us whether it is worth trying again
+func isTransient(err error) bool {
+	var (
+		ae  *APIError
+		ase *AssessmentError
+	)
+
+	if errors.As(err, &ae) {
+		return errors.Is(ae, ErrRateLimited) || errors.Is(ae, ErrServiceOverloaded)
+	}
+	if errors.As(err, &ase) {
+		return !ase.Permanent()
+	}
+	return isNetError(err)
+}
+
+// isNetError is true for network errors.  Those of our own making (bad URL,
+// request not built) would fail again the same way.
+func isNetError(err error) bool {
+	var (
+		ue *url.Error
+		ne net.Error
+	)
+
+	// Whatever the cause, http.Client returns a *url.Error
+	if errors.As(err, &ue) {
+		return isNetError(ue.Err)
+	}
+	if errors.As(err, &ne) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryAfter parses the header, either in seconds or as a date
+func retryAfter(h string) (time.Duration, bool) {
+	if h == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(h); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+var (
+	rndLock sync.Mutex
+	rnd     = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter returns a random value in [0, max)
+func jitter(max float64) float64 {
+	rndLock.Lock()
+	defer rndLock.Unlock()
+	return rnd.Float64() * max
+}
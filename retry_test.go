@@ -0,0 +1,186 @@
+package ssllabs
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoRetry_Backoff(t *testing.T) {
+	d, ok := NoRetry{}.Backoff(0, nil, errors.New("foo"))
+	assert.False(t, ok)
+	assert.Zero(t, d)
+}
+
+func TestNewExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	assert.Equal(t, DefaultBackoffMin, b.Min)
+	assert.Equal(t, DefaultBackoffMax, b.Max)
+	assert.Equal(t, DefaultRetry, b.MaxRetries)
+}
+
+func TestExponentialBackoff_Backoff(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	for i := 0; i < b.MaxRetries; i++ {
+		d, ok := b.Backoff(i, nil, newAPIError(HttpRetryLater, nil))
+		require.True(t, ok)
+
+		max := b.Min << uint(i)
+		if max > b.Max {
+			max = b.Max
+		}
+		assert.True(t, d >= max/2 && d <= max, "retry %d: %v", i, d)
+	}
+
+	_, ok := b.Backoff(b.MaxRetries, nil, newAPIError(HttpRetryLater, nil))
+	assert.False(t, ok)
+}
+
+func TestExponentialBackoff_Backoff2(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	d, ok := b.Backoff(0, nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	assert.True(t, ok)
+	assert.True(t, d <= b.Min)
+}
+
+func TestIsTransient(t *testing.T) {
+	td := []struct {
+		err error
+		res bool
+	}{
+		{nil, false},
+		{errors.New("nil req"), false},
+		{&url.Error{Op: "Get", URL: "foo://bar", Err: errors.New(`unsupported protocol scheme "foo"`)}, false},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: io.EOF}, true},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: &net.DNSError{Err: "timeout", IsTimeout: true}}, true},
+		{errors.Wrap(io.ErrUnexpectedEOF, "read"), true},
+		{errors.Wrap(syscall.ECONNRESET, "read"), true},
+		{newAPIError(http.StatusTooManyRequests, nil), true},
+		{newAPIError(http.StatusNotFound, nil), false},
+	}
+
+	for _, d := range td {
+		assert.Equal(t, d.res, isTransient(d.err), "%v", d.err)
+	}
+}
+
+func TestExponentialBackoff_BackoffPermanent(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	_, ok := b.Backoff(0, nil, newAPIError(http.StatusBadRequest, nil))
+	assert.False(t, ok)
+
+	_, ok = b.Backoff(0, nil, newAPIError(http.StatusInternalServerError, nil))
+	assert.False(t, ok)
+}
+
+func TestExponentialBackoff_BackoffMax(t *testing.T) {
+	b := &ExponentialBackoff{Min: time.Second, Max: 3 * time.Second, MaxRetries: 100}
+
+	d, ok := b.Backoff(80, nil, newAPIError(http.StatusTooManyRequests, nil))
+	assert.True(t, ok)
+	assert.True(t, d <= 3*time.Second)
+}
+
+func TestExponentialBackoff_RetryAfter(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", "42")
+
+	d, ok := b.Backoff(0, resp, newAPIError(http.StatusServiceUnavailable, nil))
+	assert.True(t, ok)
+	assert.Equal(t, 42*time.Second, d)
+}
+
+func TestRetryAfter(t *testing.T) {
+	_, ok := retryAfter("")
+	assert.False(t, ok)
+
+	_, ok = retryAfter("garbage")
+	assert.False(t, ok)
+
+	d, ok := retryAfter("0")
+	assert.True(t, ok)
+	assert.Zero(t, d)
+
+	d, ok = retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Zero(t, d)
+
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.True(t, d > 59*time.Minute)
+}
+
+func TestClient_CallAPIRetry(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(529)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(503).
+		SetHeader("Retry-After", "0")
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	rp := &ExponentialBackoff{Min: time.Millisecond, Max: 10 * time.Millisecond, MaxRetries: 3}
+	c, err := NewClient(Config{RetryPolicy: rp})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.Equal(t, 25, info.MaxAssessments)
+	assert.True(t, gock.IsDone())
+}
+
+func TestClient_CallAPIRetryExhausted(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	gock.New(baseURL).
+		Get("/info").
+		Times(2).
+		Reply(429)
+
+	rp := &ExponentialBackoff{Min: time.Millisecond, Max: 10 * time.Millisecond, MaxRetries: 1}
+	c, err := NewClient(Config{RetryPolicy: rp})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.Info()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.True(t, gock.IsDone())
+}
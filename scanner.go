@@ -0,0 +1,330 @@
+// scanner.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scanner is what *Client offers, use it in your code so that you can replace
+// the client by a fake one in tests or wrap it with the decorators below.
+type Scanner interface {
+	InfoContext(ctx context.Context) (*Info, error)
+	AnalyzeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (*Host, error)
+	GetGradeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (string, error)
+	GetDetailedReportWithOptions(ctx context.Context, site string, o AnalyzeOptions) (Host, error)
+	GetEndpointDataWithOptions(ctx context.Context, site string, o EndpointOptions) (*Endpoint, error)
+	GetStatusCodesContext(ctx context.Context) (*StatusCodes, error)
+}
+
+// Check that the client is a Scanner
+var _ Scanner = (*Client)(nil)
+
+// Names of the calls given to the decorators
+const (
+	CallInfo              = "info"
+	CallAnalyze           = "analyze"
+	CallGetGrade          = "getGrade"
+	CallGetDetailedReport = "getDetailedReport"
+	CallGetEndpointData   = "getEndpointData"
+	CallGetStatusCodes    = "getStatusCodes"
+)
+
+// aroundFunc runs fn, the real call, doing whatever is needed before and after
+type aroundFunc func(ctx context.Context, call, site string, fn func() error) error
+
+// wrapper is a Scanner calling around for every call to next
+type wrapper struct {
+	next   Scanner
+	around aroundFunc
+}
+
+// InfoContext implements Scanner
+func (w *wrapper) InfoContext(ctx context.Context) (*Info, error) {
+	r := &Info{}
+	err := w.around(ctx, CallInfo, "", func() (err error) {
+		r, err = w.next.InfoContext(ctx)
+		return
+	})
+	return r, err
+}
+
+// AnalyzeWithOptions implements Scanner
+func (w *wrapper) AnalyzeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (*Host, error) {
+	r := &Host{}
+	err := w.around(ctx, CallAnalyze, site, func() (err error) {
+		r, err = w.next.AnalyzeWithOptions(ctx, site, o)
+		return
+	})
+	return r, err
+}
+
+// GetGradeWithOptions implements Scanner
+func (w *wrapper) GetGradeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (string, error) {
+	r := "Z"
+	err := w.around(ctx, CallGetGrade, site, func() (err error) {
+		r, err = w.next.GetGradeWithOptions(ctx, site, o)
+		return
+	})
+	return r, err
+}
+
+// GetDetailedReportWithOptions implements Scanner
+func (w *wrapper) GetDetailedReportWithOptions(ctx context.Context, site string, o AnalyzeOptions) (Host, error) {
+	var r Host
+	err := w.around(ctx, CallGetDetailedReport, site, func() (err error) {
+		r, err = w.next.GetDetailedReportWithOptions(ctx, site, o)
+		return
+	})
+	return r, err
+}
+
+// GetEndpointDataWithOptions implements Scanner
+func (w *wrapper) GetEndpointDataWithOptions(ctx context.Context, site string, o EndpointOptions) (*Endpoint, error) {
+	r := &Endpoint{}
+	err := w.around(ctx, CallGetEndpointData, site, func() (err error) {
+		r, err = w.next.GetEndpointDataWithOptions(ctx, site, o)
+		return
+	})
+	return r, err
+}
+
+// GetStatusCodesContext implements Scanner
+func (w *wrapper) GetStatusCodesContext(ctx context.Context) (*StatusCodes, error) {
+	r := &StatusCodes{}
+	err := w.around(ctx, CallGetStatusCodes, "", func() (err error) {
+		r, err = w.next.GetStatusCodesContext(ctx)
+		return
+	})
+	return r, err
+}
+
+// NewLoggingScanner logs every call to next with its duration and error
+func NewLoggingScanner(next Scanner, l Logger) Scanner {
+	return &wrapper{
+		next: next,
+		around: func(ctx context.Context, call, site string, fn func() error) error {
+			start := time.Now()
+			err := fn()
+			if err != nil {
+				l.Info(call, "site", site, "duration", time.Since(start), "error", err)
+				return err
+			}
+			l.Info(call, "site", site, "duration", time.Since(start))
+			return nil
+		},
+	}
+}
+
+// Metrics receives one observation per call
+type Metrics interface {
+	Observe(call string, d time.Duration, err error)
+}
+
+// NewMetricsScanner sends the duration and outcome of every call to next to m
+func NewMetricsScanner(next Scanner, m Metrics) Scanner {
+	return &wrapper{
+		next: next,
+		around: func(ctx context.Context, call, site string, fn func() error) error {
+			start := time.Now()
+			err := fn()
+			m.Observe(call, time.Since(start), err)
+			return err
+		},
+	}
+}
+
+// CallStat is what CallStats keeps for one call
+type CallStat struct {
+	Calls    int
+	Errors   int
+	Duration time.Duration
+}
+
+// CallStats is a simple Metrics, safe for concurrent use
+type CallStats struct {
+	mu    sync.Mutex
+	stats map[string]CallStat
+}
+
+// NewCallStats returns empty stats
+func NewCallStats() *CallStats {
+	return &CallStats{stats: map[string]CallStat{}}
+}
+
+// Observe implements Metrics
+func (s *CallStats) Observe(call string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stats[call]
+	st.Calls++
+	st.Duration += d
+	if err != nil {
+		st.Errors++
+	}
+	s.stats[call] = st
+}
+
+// Get returns the stats for call
+func (s *CallStats) Get(call string) CallStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats[call]
+}
+
+// bucket is a token bucket, one token every "every", at most burst of them
+type bucket struct {
+	mu     sync.Mutex
+	every  time.Duration
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// wait blocks until a token is available
+func (b *bucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += float64(now.Sub(b.last)) / float64(b.every)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) * float64(b.every))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "rate limit")
+		case <-time.After(wait):
+		}
+	}
+}
+
+// NewRateLimitedScanner lets at most one call to next start every "every", with
+// bursts of up to burst calls (at least 1).  Waiting stops if ctx is cancelled.
+func NewRateLimitedScanner(next Scanner, every time.Duration, burst int) Scanner {
+	if burst < 1 {
+		burst = 1
+	}
+
+	b := &bucket{every: every, burst: burst, tokens: float64(burst), last: time.Now()}
+	return &wrapper{
+		next: next,
+		around: func(ctx context.Context, call, site string, fn func() error) error {
+			if every > 0 {
+				if err := b.wait(ctx); err != nil {
+					return err
+				}
+			}
+			return fn()
+		},
+	}
+}
+
+// forcer is implemented by the Client, for Config.Force, and the decorators
+// so that the caching one knows about Force whatever is between them.
+type forcer interface {
+	forced(o AnalyzeOptions) AnalyzeOptions
+}
+
+// forcedBy applies Config.Force of the Client behind s, if any
+func forcedBy(s Scanner, o AnalyzeOptions) AnalyzeOptions {
+	if f, ok := s.(forcer); ok {
+		return f.forced(o)
+	}
+	return o
+}
+
+// forced implements forcer
+func (w *wrapper) forced(o AnalyzeOptions) AnalyzeOptions {
+	return forcedBy(w.next, o)
+}
+
+// cachingScanner keeps the reports of next in a Cache
+type cachingScanner struct {
+	Scanner
+	cache  Cache
+	policy GradePolicy
+}
+
+// NewCachingScanner keeps the reports of next in cache until they expire, like
+// Config.Cache does for the Client.  GetGrade is computed from the cached
+// report with policy.  Options with StartNew always go to next, as do GetGrade
+// and GetDetailedReport if next is a Client (decorated or not) with
+// Config.Force, like the Client does.
+func NewCachingScanner(next Scanner, cache Cache, policy GradePolicy) Scanner {
+	return &cachingScanner{Scanner: next, cache: cache, policy: policy}
+}
+
+// forced implements forcer
+func (s *cachingScanner) forced(o AnalyzeOptions) AnalyzeOptions {
+	return forcedBy(s.Scanner, o)
+}
+
+// report gets the report from the cache or fn
+func (s *cachingScanner) report(site string, o AnalyzeOptions, fn func() (*Host, error)) (*Host, error) {
+	opts := o.Encode()
+	opts["host"] = site
+	key := cacheKey(opts)
+
+	if !o.StartNew {
+		if lr, ok := s.cache.Get(key); ok {
+			return lr, nil
+		}
+	}
+
+	lr, err := fn()
+	if err == nil && !expired(lr) {
+		// Not being able to cache is not an error for the caller
+		_ = s.cache.Set(key, lr)
+	}
+	return lr, err
+}
+
+// AnalyzeWithOptions implements Scanner
+func (s *cachingScanner) AnalyzeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (*Host, error) {
+	return s.report(site, o, func() (*Host, error) {
+		return s.Scanner.AnalyzeWithOptions(ctx, site, o)
+	})
+}
+
+// GetGradeWithOptions implements Scanner
+func (s *cachingScanner) GetGradeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (string, error) {
+	lr, err := s.AnalyzeWithOptions(ctx, site, s.forced(o))
+	if err != nil {
+		return "Z", errors.Wrap(err, "GetGrade")
+	}
+	return s.policy.Grade(lr)
+}
+
+// GetDetailedReportWithOptions implements Scanner
+func (s *cachingScanner) GetDetailedReportWithOptions(ctx context.Context, site string, o AnalyzeOptions) (Host, error) {
+	if o.All == AllDefault {
+		o.All = AllDone
+	}
+	o = s.forced(o)
+
+	lr, err := s.report(site, o, func() (*Host, error) {
+		lr, err := s.Scanner.GetDetailedReportWithOptions(ctx, site, o)
+		return &lr, err
+	})
+	if err != nil {
+		return Host{}, err
+	}
+	return *lr, nil
+}
@@ -0,0 +1,248 @@
+package ssllabs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScanner answers everything at once and counts the calls
+type fakeScanner struct {
+	mu    sync.Mutex
+	calls map[string]int
+	err   error
+}
+
+func newFakeScanner() *fakeScanner {
+	return &fakeScanner{calls: map[string]int{}}
+}
+
+func (f *fakeScanner) count(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[call]++
+}
+
+func (f *fakeScanner) report(site string) *Host {
+	return &Host{
+		Host:            site,
+		Status:          "READY",
+		CacheExpiryTime: NewTimestamp(time.Now().Add(time.Hour)),
+		Endpoints: []Endpoint{
+			{IPAddress: "192.0.2.1", StatusMessage: "Ready", Grade: "A"},
+			{IPAddress: "192.0.2.2", StatusMessage: "Ready", Grade: "B"},
+		},
+	}
+}
+
+func (f *fakeScanner) InfoContext(ctx context.Context) (*Info, error) {
+	f.count(CallInfo)
+	return &Info{MaxAssessments: 25}, f.err
+}
+
+func (f *fakeScanner) AnalyzeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (*Host, error) {
+	f.count(CallAnalyze)
+	return f.report(site), f.err
+}
+
+func (f *fakeScanner) GetGradeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (string, error) {
+	f.count(CallGetGrade)
+	return "A", f.err
+}
+
+func (f *fakeScanner) GetDetailedReportWithOptions(ctx context.Context, site string, o AnalyzeOptions) (Host, error) {
+	f.count(CallGetDetailedReport)
+	return *f.report(site), f.err
+}
+
+func (f *fakeScanner) GetEndpointDataWithOptions(ctx context.Context, site string, o EndpointOptions) (*Endpoint, error) {
+	f.count(CallGetEndpointData)
+	return &f.report(site).Endpoints[0], f.err
+}
+
+func (f *fakeScanner) GetStatusCodesContext(ctx context.Context) (*StatusCodes, error) {
+	f.count(CallGetStatusCodes)
+	return &StatusCodes{}, f.err
+}
+
+// every calls all methods once
+func every(t *testing.T, s Scanner) {
+	ctx := context.Background()
+	o := DefaultAnalyzeOptions()
+
+	_, err := s.InfoContext(ctx)
+	assert.NoError(t, err)
+	_, err = s.AnalyzeWithOptions(ctx, "example.com", o)
+	assert.NoError(t, err)
+	_, err = s.GetGradeWithOptions(ctx, "example.com", o)
+	assert.NoError(t, err)
+	_, err = s.GetDetailedReportWithOptions(ctx, "example.com", o)
+	assert.NoError(t, err)
+	_, err = s.GetEndpointDataWithOptions(ctx, "example.com", DefaultEndpointOptions())
+	assert.NoError(t, err)
+	_, err = s.GetStatusCodesContext(ctx)
+	assert.NoError(t, err)
+}
+
+var allCalls = []string{CallInfo, CallAnalyze, CallGetGrade, CallGetDetailedReport, CallGetEndpointData, CallGetStatusCodes}
+
+func TestMetricsScanner(t *testing.T) {
+	f := newFakeScanner()
+	stats := NewCallStats()
+
+	every(t, NewMetricsScanner(f, stats))
+
+	for _, call := range allCalls {
+		assert.Equal(t, 1, f.calls[call], call)
+		assert.Equal(t, 1, stats.Get(call).Calls, call)
+		assert.Equal(t, 0, stats.Get(call).Errors, call)
+	}
+
+	f.err = errors.New("boom")
+	_, err := NewMetricsScanner(f, stats).InfoContext(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, CallStat{Calls: 2, Errors: 1, Duration: stats.Get(CallInfo).Duration}, stats.Get(CallInfo))
+}
+
+func TestLoggingScanner(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := newFakeScanner()
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	every(t, NewLoggingScanner(f, l))
+	assert.Contains(t, buf.String(), "msg=analyze site=example.com")
+	assert.Contains(t, buf.String(), "msg=info site=\"\"")
+
+	f.err = errors.New("boom")
+	_, err := NewLoggingScanner(f, l).GetGradeWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "error=boom")
+}
+
+func TestRateLimitedScanner(t *testing.T) {
+	f := newFakeScanner()
+	s := NewRateLimitedScanner(f, 20*time.Millisecond, 2)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := s.InfoContext(context.Background())
+		require.NoError(t, err)
+	}
+	// 2 at once, then 2 waiting 20ms each
+	assert.True(t, time.Since(start) >= 35*time.Millisecond)
+	assert.Equal(t, 4, f.calls[CallInfo])
+}
+
+func TestRateLimitedScanner_Cancelled(t *testing.T) {
+	f := newFakeScanner()
+	s := NewRateLimitedScanner(f, time.Hour, 0)
+
+	_, err := s.InfoContext(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = s.InfoContext(ctx)
+	require.Error(t, err)
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.Equal(t, 1, f.calls[CallInfo])
+}
+
+func TestCachingScanner(t *testing.T) {
+	f := newFakeScanner()
+	s := NewCachingScanner(f, NewMemoryCache(10), GradeBest)
+
+	for i := 0; i < 3; i++ {
+		every(t, s)
+	}
+
+	// Only once for the cached calls
+	assert.Equal(t, 1, f.calls[CallAnalyze])
+	assert.Equal(t, 0, f.calls[CallGetGrade])
+	assert.Equal(t, 1, f.calls[CallGetDetailedReport])
+	assert.Equal(t, 3, f.calls[CallInfo])
+	assert.Equal(t, 3, f.calls[CallGetEndpointData])
+
+	grade, err := s.GetGradeWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "A", grade)
+
+	o := AnalyzeOptions{StartNew: true}
+	_, err = s.AnalyzeWithOptions(context.Background(), "example.com", o)
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.calls[CallAnalyze])
+}
+
+// Decorators compose around any Scanner, the client included
+func TestScanner_Compose(t *testing.T) {
+	var s Scanner
+
+	c, err := NewClient()
+	require.NoError(t, err)
+	s = NewMetricsScanner(NewLoggingScanner(c, NewSlogLogger(nil)), NewCallStats())
+	assert.NotNil(t, s)
+
+	f := newFakeScanner()
+	stats := NewCallStats()
+	s = NewMetricsScanner(NewRateLimitedScanner(NewCachingScanner(f, NewMemoryCache(0), GradeWorst), time.Millisecond, 10), stats)
+
+	grade, err := s.GetGradeWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "B", grade)
+	assert.Equal(t, 1, stats.Get(CallGetGrade).Calls)
+	assert.Equal(t, 1, f.calls[CallAnalyze])
+}
+
+// Config.Force of the Client is seen through the other decorators
+func TestCachingScanner_Force(t *testing.T) {
+	Before(t)
+
+	var started int
+
+	srv := scripted(fmt.Sprintf(`{"host":"example.com","status":"READY","cacheExpiryTime":%d,"endpoints":[{"ipAddress":"192.0.2.1","grade":"A","statusMessage":"Ready"}]}`,
+		time.Now().Add(time.Hour).UnixNano()/1e6))
+	defer srv.Close()
+
+	c, err := NewClient(Config{
+		BaseURL:     srv.URL,
+		Force:       true,
+		Poller:      NoWaitPoller{},
+		RetryPolicy: NoRetry{},
+		Hooks: Hooks{
+			OnRequest: func(ctx context.Context, ev RequestEvent) {
+				if strings.Contains(ev.URL, "startNew=on") {
+					started++
+				}
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	s := NewCachingScanner(NewMetricsScanner(c, NewCallStats()), NewMemoryCache(10), GradeWorst)
+
+	for i := 0; i < 2; i++ {
+		grade, err := s.GetGradeWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+		require.NoError(t, err)
+		assert.Equal(t, "A", grade)
+
+		_, err = s.GetDetailedReportWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, started)
+
+	// Like the Client, Analyze does not look at Force
+	_, err = s.AnalyzeWithOptions(context.Background(), "example.com", DefaultAnalyzeOptions())
+	require.NoError(t, err)
+	assert.Equal(t, 4, started)
+}
@@ -1,25 +1,32 @@
 package ssllabs // import "github.com/keltia/ssllabs"
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/keltia/proxy"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 /*
-SSLabs API v3
+SSLabs API v3 & v4
 
 https://github.com/ssllabs/ssllabs-scan/blob/master/ssllabs-api-docs-v3.md
+https://github.com/ssllabs/ssllabs-scan/blob/master/ssllabs-api-docs-v4.md
 
-GET only, no POST
+GET only, except for the v4 register call which is a POST.  v4 requires an
+"email" header registered beforehand with Register().
 */
 
 const (
-	baseURL = "https://api.ssllabs.com/api/v3"
+	baseURL   = "https://api.ssllabs.com/api/v3"
+	baseURLv4 = "https://api.ssllabs.com/api/v4"
+
+	// DefaultAPIVersion is still v3 for compatibility
+	DefaultAPIVersion = 3
 
 	// DefaultWait is the timeout
 	DefaultWait = 10 * time.Second
@@ -34,14 +41,31 @@ const (
 	MyName = "ssllabs"
 )
 
-// Client is the main datatype for requests
+// Client is the main datatype for requests.
+//
+// A Client is safe for concurrent use by multiple goroutines: its configuration
+// is set by NewClient and never changes afterwards, everything specific to one
+// call (options, force, polling state) lives in that call.  The limiter and the
+// cache are shared on purpose.
 type Client struct {
 	baseurl   string
 	level     int
 	timeout   time.Duration
 	retries   int
 	force     bool
-	proxyauth string
+	proxyauth bool
+	version   int
+	email     string
+	useragent string
+	retry     RetryPolicy
+	poller    Poller
+	limiter   *limiter
+	logger    Logger
+	policy    GradePolicy
+	cache     Cache
+	hooks     Hooks
+	tracer    trace.Tracer
+	strict    bool
 
 	client *http.Client
 }
@@ -53,6 +77,55 @@ type Config struct {
 	Timeout int
 	Retries int
 	Force   bool // set fromCache to "off"
+
+	// APIVersion is 3 (default) or 4, v4 requires Email
+	APIVersion int
+	// Email is the address registered with SSLLabs, sent with every v4 request
+	Email string
+
+	// RetryPolicy is used for transient errors, default is ExponentialBackoff
+	RetryPolicy RetryPolicy
+
+	// Poller schedules the polls of Analyze, default is AdaptivePoller
+	Poller Poller
+
+	// Logger gets structured records, default uses the log package and Log as level
+	Logger Logger
+
+	// GradePolicy combines the grades of all endpoints in GetGrade, default is GradeWorst
+	GradePolicy GradePolicy
+
+	// Cache keeps finished reports until they expire, default is no cache
+	Cache Cache
+
+	// Cassette is a directory where conversations are recorded or replayed from,
+	// depending on CassetteMode.  When replaying, Poller defaults to no waiting.
+	Cassette     string
+	CassetteMode RecorderMode
+
+	// HTTPClient is used for every call instead of our own, Timeout, Transport
+	// and the proxy settings are then ignored.
+	HTTPClient *http.Client
+	// Transport replaces the one built from the environment, the proxy
+	// settings are then ignored.
+	Transport http.RoundTripper
+	// Proxy is the URL of the proxy to use instead of the environment ones
+	// (HTTPS_PROXY, etc.), with ProxyUser & ProxyPassword instead of .netrc
+	Proxy         string
+	ProxyUser     string
+	ProxyPassword string
+	// UserAgent is sent with every request, default is "ssllabs/MyVersion"
+	UserAgent string
+
+	// Hooks are called for every request and assessment status change
+	Hooks Hooks
+
+	// TracerProvider creates the OpenTelemetry spans, default is the global one
+	TracerProvider trace.TracerProvider
+
+	// StrictDecode checks every answer against our types, differences go to
+	// Hooks.OnSchemaDrift and into the Drift of reports instead of failing
+	StrictDecode bool
 }
 
 // NewClient create the context for new connections
@@ -66,14 +139,26 @@ func NewClient(cnf ...Config) (*Client, error) {
 			timeout: DefaultWait,
 			retries: DefaultRetry,
 			force:   false,
+			version: DefaultAPIVersion,
+			poller:  NewAdaptivePoller(),
 		}
 	} else {
 		c = &Client{
-			baseurl: cnf[0].BaseURL,
-			level:   cnf[0].Log,
-			retries: cnf[0].Retries,
-			timeout: toDuration(cnf[0].Timeout) * time.Second,
-			force:   cnf[0].Force,
+			baseurl:   cnf[0].BaseURL,
+			level:     cnf[0].Log,
+			retries:   cnf[0].Retries,
+			timeout:   toDuration(cnf[0].Timeout) * time.Second,
+			force:     cnf[0].Force,
+			version:   cnf[0].APIVersion,
+			email:     cnf[0].Email,
+			useragent: cnf[0].UserAgent,
+			retry:     cnf[0].RetryPolicy,
+			poller:    cnf[0].Poller,
+			logger:    cnf[0].Logger,
+			policy:    cnf[0].GradePolicy,
+			cache:     cnf[0].Cache,
+			hooks:     cnf[0].Hooks,
+			strict:    cnf[0].StrictDecode,
 		}
 
 		if cnf[0].Timeout == 0 {
@@ -86,50 +171,170 @@ func NewClient(cnf ...Config) (*Client, error) {
 		if c.retries == 0 {
 			c.retries = DefaultRetry
 		}
+		if c.poller == nil {
+			c.poller = NewAdaptivePoller()
+			if cnf[0].Cassette != "" && cnf[0].CassetteMode == ModeReplay {
+				c.poller = NoWaitPoller{}
+			}
+		}
+
+		if c.policy < GradeWorst || c.policy > GradeMajority {
+			return nil, fmt.Errorf("unknown grade policy %d", int(c.policy))
+		}
+
+		switch c.version {
+		case 0:
+			c.version = DefaultAPIVersion
+		case 3:
+		case 4:
+			if c.email == "" {
+				return nil, errors.New("API v4 requires an email")
+			}
+		default:
+			return nil, fmt.Errorf("unknown API version %d", c.version)
+		}
+
 		// Ensure we have the API endpoint right
 		if c.baseurl == "" {
 			c.baseurl = baseURL
+			if c.version == 4 {
+				c.baseurl = baseURLv4
+			}
 		}
 
-		c.debug("got cnf: %#v", cnf[0])
 	}
 
-	c.verbose("client created")
-	// We do not care whether it fails or not, if it does, just no proxyauth.
-	proxyauth, _ := proxy.SetupProxyAuth()
+	if c.logger == nil {
+		c.logger = stdLogger{level: c.level}
+	}
+
+	if c.useragent == "" {
+		c.useragent = fmt.Sprintf("%s/%s", MyName, MyVersion)
+	}
 
-	// Save it
-	c.proxyauth = proxyauth
-	c.debug("got proxyauth: %s", c.proxyauth)
+	// Retries are for transient errors
+	if c.retry == nil {
+		rp := NewExponentialBackoff()
+		rp.MaxRetries = c.retries
+		c.retry = rp
+	}
 
-	_, trsp := proxy.SetupTransport(c.baseurl)
-	c.client = &http.Client{
-		Transport:     trsp,
-		Timeout:       c.timeout,
-		CheckRedirect: myRedirect,
+	c.limiter = newLimiter()
+
+	var hcnf Config
+	if len(cnf) != 0 {
+		hcnf = cnf[0]
+	}
+	if err := c.setupHTTP(hcnf); err != nil {
+		return nil, errors.Wrap(err, "NewClient")
 	}
-	c.debug("newclient: c=%#v", c)
+
+	c.tracer = newTracer(hcnf.TracerProvider)
+
+	c.verbose("client created",
+		"baseurl", c.baseurl,
+		"version", c.version,
+		"timeout", c.timeout,
+		"retries", c.retries,
+		"force", c.force,
+		"email", redact(c.email),
+		"useragent", c.useragent,
+		"proxyauth", c.proxyauth)
 
 	return c, nil
 }
 
+// APIVersion returns the version of the API in use
+func (c *Client) APIVersion() int {
+	return c.version
+}
+
+// Register implements the register API call (v4 only), it is needed only once
+// per email.  If r.Email is empty, Config.Email is used.
+func (c *Client) Register(r Registration) (*RegisterResponse, error) {
+	return c.RegisterContext(context.Background(), r)
+}
+
+// RegisterContext is Register with a context for cancellation & deadlines
+func (c *Client) RegisterContext(ctx context.Context, r Registration) (*RegisterResponse, error) {
+	if c.version < 4 {
+		return &RegisterResponse{}, errors.New("register needs API v4")
+	}
+
+	if r.Email == "" {
+		r.Email = c.email
+	}
+	if r.FirstName == "" || r.LastName == "" || r.Email == "" || r.Organization == "" {
+		return &RegisterResponse{}, errors.New("incomplete registration")
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return &RegisterResponse{}, errors.Wrap(err, "Register")
+	}
+
+	raw, err := c.callAPI(ctx, "register", string(body), map[string]string{})
+	if err != nil {
+		return &RegisterResponse{}, errors.Wrap(err, "Register")
+	}
+
+	var rr RegisterResponse
+
+	sd, err := c.decode(raw, &rr)
+	c.reportDrift(ctx, "register", sd)
+	return &rr, errors.Wrapf(err, "Register - %v", string(raw))
+}
+
 // Info implements the Info() API call
 func (c *Client) Info() (*Info, error) {
+	return c.InfoContext(context.Background())
+}
+
+// InfoContext is Info with a context for cancellation & deadlines
+func (c *Client) InfoContext(ctx context.Context) (*Info, error) {
 	// No parameter
 	opts := map[string]string{}
-	raw, err := c.callAPI("info", "", opts)
+	raw, err := c.callAPI(ctx, "info", "", opts)
 	if err != nil {
 		return &Info{}, errors.Wrap(err, "Info")
 	}
 
 	var li Info
 
-	err = json.Unmarshal(raw, &li)
+	sd, err := c.decode(raw, &li)
+	c.reportDrift(ctx, "info", sd)
+	if err == nil {
+		c.limiter.seed(&li)
+	}
 	return &li, errors.Wrapf(err, "Info - %v", string(raw))
 }
 
+// reserve waits until we are allowed to start a new assessment, calling Info()
+// only the first time to learn about the limits and the cool-off.
+func (c *Client) reserve(ctx context.Context) error {
+	if !c.limiter.isSeeded() {
+		inf, err := c.InfoContext(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Can not call Info()")
+		}
+		c.debug("limits", "max", inf.MaxAssessments, "current", inf.CurrentAssessments, "cooloff", inf.NewAssessmentCoolOff)
+	}
+	return c.limiter.acquire(ctx)
+}
+
 // GetGrade is the basic call — equal to getEndpointData and extracting just the grade.
+// Grades of all endpoints are combined with Config.GradePolicy, it fails only if
+// none has a grade.
+//
+// Deprecated: map options are not checked, use GetGradeWithOptions.
 func (c *Client) GetGrade(site string, myopts ...map[string]string) (string, error) {
+	return c.GetGradeContext(context.Background(), site, myopts...)
+}
+
+// GetGradeContext is GetGrade with a context for cancellation & deadlines
+//
+// Deprecated: map options are not checked, use GetGradeWithOptions.
+func (c *Client) GetGradeContext(ctx context.Context, site string, myopts ...map[string]string) (string, error) {
 	if site == "" {
 		return "Z", errors.New("empty site")
 	}
@@ -143,22 +348,37 @@ func (c *Client) GetGrade(site string, myopts ...map[string]string) (string, err
 		}
 	}
 
-	lr, err := c.Analyze(site, c.force, []map[string]string{opts}...)
+	lr, err := c.AnalyzeContext(ctx, site, c.force, []map[string]string{opts}...)
 	if err != nil {
 		return "Z", errors.Wrap(err, "GetGrade")
 	}
+	return c.gradeOf(lr)
+}
+
+// GetGradeWithOptions is GetGrade with typed options, Config.Force still applies
+func (c *Client) GetGradeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (string, error) {
+	if site == "" {
+		return "Z", errors.New("empty site")
+	}
 
-	if len(lr.Endpoints) != 0 {
-		if lr.Endpoints[0].StatusMessage != "Ready" {
-			return "Z", fmt.Errorf("error: %s", lr.Endpoints[0].StatusMessage)
-		}
-		return lr.Endpoints[0].Grade, errors.Wrapf(err, "GetGrade - %v", lr)
+	lr, err := c.AnalyzeWithOptions(ctx, site, c.forced(o))
+	if err != nil {
+		return "Z", errors.Wrap(err, "GetGrade")
 	}
-	return "Z", errors.New("no endpoint")
+	return c.gradeOf(lr)
 }
 
 // GetDetailedReport returns the full report
+//
+// Deprecated: map options are not checked, use GetDetailedReportWithOptions.
 func (c *Client) GetDetailedReport(site string, myopts ...map[string]string) (Host, error) {
+	return c.GetDetailedReportContext(context.Background(), site, myopts...)
+}
+
+// GetDetailedReportContext is GetDetailedReport with a context for cancellation & deadlines
+//
+// Deprecated: map options are not checked, use GetDetailedReportWithOptions.
+func (c *Client) GetDetailedReportContext(ctx context.Context, site string, myopts ...map[string]string) (Host, error) {
 	if site == "" {
 		return Host{}, errors.New("empty site")
 	}
@@ -174,30 +394,75 @@ func (c *Client) GetDetailedReport(site string, myopts ...map[string]string) (Ho
 		}
 	}
 
-	c.debug("opts=%v", opts)
+	ctx, span := c.startSpan(ctx, "ssllabs.GetDetailedReport", attrHost.String(site))
 
-	lr, err := c.Analyze(site, c.force, []map[string]string{opts}...)
+	lr, err := c.AnalyzeContext(ctx, site, c.force, []map[string]string{opts}...)
 	if err != nil {
-		return Host{}, errors.Wrap(err, "GetDetailedReport")
+		err = errors.Wrap(err, "GetDetailedReport")
+		endSpan(span, err)
+		return Host{}, err
 	}
 
-	if len(lr.Endpoints) != 0 {
-		if lr.Endpoints[0].StatusMessage != "Ready" {
-			return Host{}, fmt.Errorf("error: %s", lr.Endpoints[0].StatusMessage)
-		}
-		return *lr, errors.Wrapf(err, "GetDetailedReport - %v", lr)
+	r, err := detailsOf(lr)
+	endSpan(span, err)
+	return r, err
+}
+
+// GetDetailedReportWithOptions is GetDetailedReport with typed options, All is
+// set to AllDone if left to AllDefault and Config.Force still applies.
+func (c *Client) GetDetailedReportWithOptions(ctx context.Context, site string, o AnalyzeOptions) (Host, error) {
+	if site == "" {
+		return Host{}, errors.New("empty site")
+	}
+
+	if o.All == AllDefault {
+		o.All = AllDone
+	}
+
+	ctx, span := c.startSpan(ctx, "ssllabs.GetDetailedReport", attrHost.String(site))
+
+	lr, err := c.AnalyzeWithOptions(ctx, site, c.forced(o))
+	if err != nil {
+		err = errors.Wrap(err, "GetDetailedReport")
+		endSpan(span, err)
+		return Host{}, err
+	}
+
+	r, err := detailsOf(lr)
+	endSpan(span, err)
+	return r, err
+}
+
+// detailsOf checks that the report is usable, i.e. at least one endpoint is
+// ready.  The status of the others is in the report.
+func detailsOf(lr *Host) (Host, error) {
+	grades, err := gradesOf(lr)
+	if len(grades) == 0 {
+		return Host{}, err
+	}
+	return *lr, nil
+}
+
+// forced applies Config.Force to the options
+func (c *Client) forced(o AnalyzeOptions) AnalyzeOptions {
+	if c.force {
+		o.StartNew = true
+		o.FromCache = false
 	}
-	return Host{}, errors.New("no endpoint")
+	return o
 }
 
 // Analyze submit the given host for checking
+//
+// Deprecated: map options are not checked, use AnalyzeWithOptions.
 func (c *Client) Analyze(site string, force bool, myopts ...map[string]string) (*Host, error) {
-	var (
-		raw []byte
-		err error
-		lr  Host
-	)
+	return c.AnalyzeContext(context.Background(), site, force, myopts...)
+}
 
+// AnalyzeContext is Analyze with a context, cancelling it stops the polling loop
+//
+// Deprecated: map options are not checked, use AnalyzeWithOptions.
+func (c *Client) AnalyzeContext(ctx context.Context, site string, force bool, myopts ...map[string]string) (*Host, error) {
 	// Default parameters
 	opts := map[string]string{
 		"host":           site,
@@ -218,74 +483,183 @@ func (c *Client) Analyze(site string, force bool, myopts ...map[string]string) (
 		}
 	}
 
-	c.debug("opts=%v", opts)
+	ac := analyzeCall{host: site, opts: opts}
 
-	// Call Info() to see whether we are allowed to call Analyze
-	inf, err := c.Info()
-	c.debug("inf=%#v", inf)
-	if err != nil {
-		return &Host{}, errors.Wrap(err, "Can not call Info()")
+	if force {
+		opts["all"] = "done"
+		opts["fromCache"] = "off"
+
+		// startNew only once, sending it with every poll would restart the assessment
+		ac.trigger = mergeOptions(map[string]string{}, opts)
+		ac.trigger["startNew"] = "on"
+		delete(opts, "startNew")
+	}
+
+	return c.analyze(ctx, ac)
+}
+
+// AnalyzeWithOptions submit the given host for checking.  If o.StartNew is set,
+// a new assessment is started then polled without startNew.
+func (c *Client) AnalyzeWithOptions(ctx context.Context, site string, o AnalyzeOptions) (*Host, error) {
+	if site == "" {
+		return &Host{}, errors.New("empty site")
+	}
+
+	if err := o.Validate(); err != nil {
+		return &Host{}, errors.Wrap(err, "analyze")
 	}
 
-	// Backoff if there is an issue there (avoid 429 error)
-	if inf.CurrentAssessments > inf.MaxAssessments {
-		c.debug("max reached")
-		return &Host{}, errors.New(fmt.Sprintf("max assessment reached: %d", inf.CurrentAssessments))
+	ac := analyzeCall{host: site, progress: o.Progress}
+
+	if o.StartNew {
+		ac.trigger = o.Encode()
+		ac.trigger["host"] = site
+		o.StartNew = false
+	}
+
+	ac.opts = o.Encode()
+	ac.opts["host"] = site
+
+	return c.analyze(ctx, ac)
+}
+
+// analyzeCall is what one Analyze needs, built for every call so that nothing
+// is ever written into the shared Client.
+type analyzeCall struct {
+	host string
+	// trigger, if not nil, is sent once to start a new assessment
+	trigger map[string]string
+	// opts are sent with every poll
+	opts map[string]string
+	// progress, if not nil, is called after every poll
+	progress ProgressFunc
+}
+
+// analyze runs the assessment in its own span
+func (c *Client) analyze(ctx context.Context, ac analyzeCall) (*Host, error) {
+	ctx, span := c.startSpan(ctx, "ssllabs.Analyze", attrHost.String(ac.host), attrStartNew.Bool(ac.trigger != nil))
+
+	lr, err := c.assessment(ctx, span, ac)
+	endSpan(span, err)
+	return lr, err
+}
+
+// assessment does the real work: ac.trigger, if not nil, is sent once to start a
+// new assessment then we poll with ac.opts until the end.  Without trigger, a
+// report still valid in the cache is used instead.
+func (c *Client) assessment(ctx context.Context, span trace.Span, ac analyzeCall) (*Host, error) {
+	var (
+		raw []byte
+		err error
+		lr  Host
+		sd  *SchemaDrift
+	)
+
+	host := ac.host
+	key := cacheKey(ac.opts)
+
+	if c.cache != nil && ac.trigger == nil {
+		if cached, ok := c.cache.Get(key); ok {
+			c.debug("cache hit", "host", host, "expires", cached.CacheExpiryTime)
+			span.SetAttributes(attrCacheHit.Bool(true), attrStatus.String(cached.Status))
+			return cached, nil
+		}
 	}
 
 	// Trigger the analyze
-	if force {
-		opts["all"] = "done"
-		opts["startNew"] = "on"
-		opts["fromCache"] = "off"
+	if ac.trigger != nil {
+		// Wait for a free slot (avoid 429 error)
+		if err := c.reserve(ctx); err != nil {
+			return &Host{}, errors.Wrap(err, "analyze/reserve")
+		}
+		defer c.limiter.release()
+
+		c.verbose("new assessment", "host", host)
 
-		raw, err := c.callAPI("analyze", "", opts)
+		_, err := c.callAPI(ctx, "analyze", "", ac.trigger)
 		if err != nil {
 			return &Host{}, errors.Wrap(err, "analyze/trigger")
 		}
-
-		// When forcing the whole test, retries are set much higher because scanning
-		// can take a long time
-		c.retries = c.retries * 3
-
-		// Have a look at the body
-		c.debug("raw=%v", string(raw))
 	}
 
-	retry := 0
-	for {
-		if retry >= c.retries {
-			return &Host{}, fmt.Errorf("retries exceeded raw=%v", string(raw))
-		}
+	status := ""
+	ready := map[string]bool{}
 
-		raw, err = c.callAPI("analyze", "", opts)
+	start := time.Now()
+	for poll := 0; ; poll++ {
+		raw, err = c.callAPI(ctx, "analyze", "", ac.opts)
 		if err != nil {
 			return &Host{}, errors.Wrap(err, "analyze/loop")
 		}
 
-		err = json.Unmarshal(raw, &lr)
+		// Do not keep anything from the previous poll
+		lr = Host{}
+		sd, err = c.decode(raw, &lr)
 		if err != nil {
 			return &Host{}, errors.Wrapf(err, "analyze/unmarshal: %s", string(raw))
 		}
 
-		c.debug("lr=%#v", lr)
-		c.debug("raw=%v", string(raw))
+		if lr.Status != status {
+			c.verbose("status", "host", host, "from", status, "to", lr.Status, "message", lr.StatusMessage)
+			c.hooks.statusChange(ctx, StatusEvent{Host: host, From: status, To: lr.Status, Message: lr.StatusMessage, Poll: poll})
+			status = lr.Status
+		}
+
+		reportProgress(ac.progress, &lr, poll)
+		c.hooks.endpointsReady(ctx, &lr, ready)
+		pollEvent(span, &lr, poll)
 
 		// End of analysis
-		if lr.Status == "READY" || lr.Status == "ERROR " {
-			c.debug("out-of-loop")
+		if lr.Status == "READY" || lr.Status == "ERROR" {
+			span.SetAttributes(attrStatus.String(lr.Status), attrPolls.Int(poll+1))
+		}
+		if lr.Status == "READY" {
+			c.debug("poll", "host", host, "poll", poll, "status", lr.Status, "done", true)
+			lr.Drift = c.reportDrift(ctx, "analyze", sd)
+			c.store(key, &lr)
 			break
 		}
+		if lr.Status == "ERROR" {
+			c.debug("poll", "host", host, "poll", poll, "status", lr.Status, "done", true)
+			return &Host{}, newAssessmentError(&lr)
+		}
+
+		wait, ok := c.poller.Next(&lr, time.Since(start))
+		if !ok {
+			return &Host{}, fmt.Errorf("polling budget exceeded after %d polls raw=%v", poll+1, string(raw))
+		}
+
+		c.debug("poll", "host", host, "poll", poll, "status", lr.Status, "progress", newProgressEvent(&lr, poll).Progress(), "wait", wait)
+		select {
+		case <-ctx.Done():
+			return &Host{}, errors.Wrap(ctx.Err(), "analyze/wait")
+		case <-time.After(wait):
+		}
+	}
+	return &lr, nil
+}
 
-		c.debug("loop")
-		time.Sleep(2 * time.Second)
-		retry++
+// store keeps the report in the cache, if any, and if it has an expiry time
+func (c *Client) store(key string, lr *Host) {
+	if c.cache == nil || expired(lr) {
+		return
+	}
+	if err := c.cache.Set(key, lr); err != nil {
+		c.verbose("cache", "host", lr.Host, "error", err)
 	}
-	return &lr, errors.Wrapf(err, "analyze/end: %s", string(raw))
 }
 
 // GetEndpointData returns the endpoint data, no analyze run if not available
+//
+// Deprecated: map options are not checked, use GetEndpointDataWithOptions.
 func (c *Client) GetEndpointData(site string, myopts ...map[string]string) (*Endpoint, error) {
+	return c.GetEndpointDataContext(context.Background(), site, myopts...)
+}
+
+// GetEndpointDataContext is GetEndpointData with a context for cancellation & deadlines
+//
+// Deprecated: map options are not checked, use GetEndpointDataWithOptions.
+func (c *Client) GetEndpointDataContext(ctx context.Context, site string, myopts ...map[string]string) (*Endpoint, error) {
 	// Default parameters
 	opts := map[string]string{
 		"host":      site,
@@ -302,31 +676,57 @@ func (c *Client) GetEndpointData(site string, myopts ...map[string]string) (*End
 			opts = mergeOptions(opts, o)
 		}
 	}
+	return c.getEndpointData(ctx, opts)
+}
+
+// GetEndpointDataWithOptions returns the endpoint data with typed options
+func (c *Client) GetEndpointDataWithOptions(ctx context.Context, site string, o EndpointOptions) (*Endpoint, error) {
+	if site == "" {
+		return &Endpoint{}, errors.New("empty site")
+	}
+
+	if err := o.Validate(); err != nil {
+		return &Endpoint{}, errors.Wrap(err, "GetEndpointData")
+	}
+
+	opts := o.Encode()
+	opts["host"] = site
 
-	raw, err := c.callAPI("getEndpointData", "", opts)
+	return c.getEndpointData(ctx, opts)
+}
+
+func (c *Client) getEndpointData(ctx context.Context, opts map[string]string) (*Endpoint, error) {
+	raw, err := c.callAPI(ctx, "getEndpointData", "", opts)
 	if err != nil {
 		return &Endpoint{}, errors.Wrap(err, "GetEndpointData")
 	}
 
 	var le Endpoint
 
-	err = json.Unmarshal(raw, &le)
-	return &le, errors.Wrapf(err, "GetEndpointData - %v", raw)
+	sd, err := c.decode(raw, &le)
+	le.Drift = c.reportDrift(ctx, "getEndpointData", sd)
+	return &le, errors.Wrapf(err, "GetEndpointData - %v", string(raw))
 }
 
 // GetStatusCodes returns all codes & their translation
 func (c *Client) GetStatusCodes() (*StatusCodes, error) {
+	return c.GetStatusCodesContext(context.Background())
+}
+
+// GetStatusCodesContext is GetStatusCodes with a context for cancellation & deadlines
+func (c *Client) GetStatusCodesContext(ctx context.Context) (*StatusCodes, error) {
 	// No parameters
 	opts := map[string]string{}
 
-	raw, err := c.callAPI("getStatusCodes", "", opts)
+	raw, err := c.callAPI(ctx, "getStatusCodes", "", opts)
 	if err != nil {
 		return &StatusCodes{}, errors.Wrap(err, "GetStatusCodes")
 	}
 
 	var sc StatusCodes
 
-	err = json.Unmarshal(raw, &sc)
+	sd, err := c.decode(raw, &sc)
+	c.reportDrift(ctx, "getStatusCodes", sd)
 	return &sc, errors.Wrapf(err, "GetStatusCodes - %v", string(raw))
 }
 
@@ -2,13 +2,16 @@ package ssllabs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/h2non/gock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +67,53 @@ func TestNewClient5(t *testing.T) {
 	assert.Equal(t, time.Duration(10)*time.Second, c.timeout)
 }
 
+func TestNewClient6(t *testing.T) {
+	conf := Config{Retries: 2}
+	c, err := NewClient(conf)
+
+	assert.NoError(t, err)
+	require.NotNil(t, c)
+
+	require.IsType(t, (*ExponentialBackoff)(nil), c.retry)
+	assert.Equal(t, 2, c.retry.(*ExponentialBackoff).MaxRetries)
+	assert.IsType(t, (*AdaptivePoller)(nil), c.poller)
+}
+
+func TestNewClientV4(t *testing.T) {
+	conf := Config{APIVersion: 4, Email: "john@example.com"}
+	c, err := NewClient(conf)
+
+	assert.NoError(t, err)
+	require.NotNil(t, c)
+
+	assert.Equal(t, baseURLv4, c.baseurl)
+	assert.Equal(t, 4, c.APIVersion())
+	assert.Equal(t, "john@example.com", c.email)
+}
+
+func TestNewClientV4_NoEmail(t *testing.T) {
+	c, err := NewClient(Config{APIVersion: 4})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestNewClient_BadVersion(t *testing.T) {
+	c, err := NewClient(Config{APIVersion: 2})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestNewClient_DefaultVersion(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAPIVersion, c.APIVersion())
+
+	c, err = NewClient(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAPIVersion, c.APIVersion())
+	assert.Equal(t, baseURL, c.baseurl)
+}
+
 func Before(t *testing.T) {
 	os.Unsetenv("http_proxy")
 	os.Unsetenv("https_proxy")
@@ -108,7 +158,6 @@ func TestClient_AnalyzeForceFull(t *testing.T) {
 		"publish":        "off",
 		"maxAge":         "24",
 		"fromCache":      "off",
-		"startNew":       "on",
 		"ignoreMismatch": "on",
 	}
 
@@ -135,9 +184,16 @@ func TestClient_AnalyzeForceFull(t *testing.T) {
 		Reply(200).
 		BodyString(string(ftp))
 
+	// Polls must not have startNew
+	m := gock.NewMatcher()
+	m.Add(func(req *http.Request, _ *gock.Request) (bool, error) {
+		return req.URL.Query().Get("startNew") == "", nil
+	})
+
 	gock.New(baseURL).
 		Get("/analyze").
 		MatchParams(opts2).
+		SetMatcher(m).
 		Reply(200).
 		BodyString(string(ftc))
 
@@ -190,6 +246,86 @@ func TestClient_AnalyzeForceFull_BadInfo(t *testing.T) {
 	assert.Empty(t, an)
 }
 
+func TestClient_AnalyzeContextCancelled(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	c, err := NewClient()
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.NotEmpty(t, c)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	an, err := c.AnalyzeContext(ctx, site, false)
+	require.Error(t, err)
+	assert.Empty(t, an)
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+}
+
+// Deadline hit while waiting between two polls
+func TestClient_AnalyzeContextDeadline(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURL).
+		Get("/info").
+		Reply(200).
+		BodyString(string(fti))
+
+	gock.New(baseURL).
+		Get("/analyze").
+		Persist().
+		Reply(200).
+		BodyString(`{"host":"ssllabs.com","status":"IN_PROGRESS"}`)
+
+	c, err := NewClient()
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	require.NotEmpty(t, c)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	an, err := c.AnalyzeContext(ctx, site, false)
+	require.Error(t, err)
+	assert.Empty(t, an)
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func TestClient_InfoContextCancelled(t *testing.T) {
+	Before(t)
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	info, err := c.InfoContext(ctx)
+	require.Error(t, err)
+	assert.Empty(t, info)
+}
+
 // From cache, full restults, no options
 func TestClient_AnalyzeCacheFull(t *testing.T) {
 	Before(t)
@@ -378,10 +514,9 @@ func TestClient_GetGradeLbl(t *testing.T) {
 	// Default parameters
 	opts := map[string]string{
 		"host":           site,
-		"all":            "done",
 		"publish":        "off",
 		"maxAge":         "24",
-		"fromCache":      "off",
+		"fromCache":      "on",
 		"ignoreMismatch": "on",
 	}
 
@@ -688,6 +823,107 @@ func TestClient_GetEndpointData3(t *testing.T) {
 	assert.Equal(t, "empty site", err.Error())
 }
 
+func TestClient_GetEndpointData4(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	site := "ssllabs.com"
+
+	gock.New(baseURL).
+		Get("/getEndpointData").
+		MatchParam("host", site).
+		Reply(200).
+		BodyString(`{"ipAddress":"64.41.200.100","progress":"done"}`)
+
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	_, err = c.GetEndpointData(site)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `GetEndpointData - {"ipAddress":"64.41.200.100","progress":"done"}`)
+}
+
+func TestClient_Register(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	reg := Registration{
+		FirstName:    "John",
+		LastName:     "Doe",
+		Email:        "john@example.com",
+		Organization: "Example",
+	}
+
+	gock.New(baseURLv4).
+		Post("/register").
+		MatchType("json").
+		JSON(reg).
+		Reply(200).
+		BodyString(`{"message":"User has been registered successfully","status":"success"}`)
+
+	c, err := NewClient(Config{APIVersion: 4, Email: "john@example.com"})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	// Email comes from the configuration
+	reg.Email = ""
+	rr, err := c.Register(reg)
+	require.NoError(t, err)
+	assert.Equal(t, "success", rr.Status)
+	assert.True(t, gock.IsDone())
+}
+
+func TestClient_RegisterV3(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	rr, err := c.Register(Registration{FirstName: "John", LastName: "Doe", Email: "john@example.com", Organization: "Example"})
+	assert.Error(t, err)
+	assert.Empty(t, rr)
+}
+
+func TestClient_RegisterIncomplete(t *testing.T) {
+	c, err := NewClient(Config{APIVersion: 4, Email: "john@example.com"})
+	require.NoError(t, err)
+
+	rr, err := c.Register(Registration{FirstName: "John"})
+	assert.Error(t, err)
+	assert.Empty(t, rr)
+}
+
+func TestClient_InfoV4(t *testing.T) {
+	Before(t)
+
+	defer gock.Off()
+
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fti)
+
+	gock.New(baseURLv4).
+		Get("/info").
+		MatchHeader("email", "john@example.com").
+		Reply(200).
+		BodyString(string(fti))
+
+	c, err := NewClient(Config{APIVersion: 4, Email: "john@example.com"})
+	require.NoError(t, err)
+
+	gock.InterceptClient(c.client)
+	defer gock.RestoreClient(c.client)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.NotEmpty(t, info)
+}
+
 func TestVersion(t *testing.T) {
 	v := Version()
 	assert.Equal(t, MyVersion, v)
@@ -0,0 +1,346 @@
+// server.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+/*
+Package ssllabstest provides an in-process fake SSLLabs server for tests.
+
+Point Config.BaseURL at Server.URL (or use Server.Config() to also avoid waiting
+between polls) and every call of the ssllabs client is answered locally.
+
+Each site follows a script: DNS, then Polls answers IN_PROGRESS with a rising
+progress, then READY with its report or ERROR.  Once finished, the same result
+is sent again until startNew is used.
+*/
+package ssllabstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keltia/ssllabs"
+)
+
+const (
+	// DefaultPolls is the number of IN_PROGRESS answers before the end
+	DefaultPolls = 2
+
+	// DefaultMaxAssessments is the quota of concurrent assessments
+	DefaultMaxAssessments = 25
+
+	// EngineVersion is what info reports
+	EngineVersion = "2.1.0"
+	// CriteriaVersion is what info reports
+	CriteriaVersion = "2009q"
+)
+
+// Site is the script for one host
+type Site struct {
+	// Report is sent once READY, see Fixture
+	Report ssllabs.Host
+	// Polls is the number of IN_PROGRESS answers, 0 for none
+	Polls int
+	// Error, if set, ends the assessment with ERROR and this status message
+	Error string
+
+	step    int
+	running bool
+}
+
+// Server is the fake SSLLabs, safe for concurrent use
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sites    map[string]*Site
+	max      int
+	current  int
+	failures []int
+	requests map[string]int
+}
+
+// NewServer starts a server, sites not added with AddSite do not resolve
+func NewServer() *Server {
+	s := &Server{
+		sites:    map[string]*Site{},
+		max:      DefaultMaxAssessments,
+		requests: map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Config returns a client configuration using the server without waiting
+// between polls nor retrying.
+func (s *Server) Config() ssllabs.Config {
+	return ssllabs.Config{
+		BaseURL:     s.URL,
+		Poller:      ssllabs.NoWaitPoller{},
+		RetryPolicy: ssllabs.NoRetry{},
+	}
+}
+
+// AddSite adds or replaces the script for host
+func (s *Server) AddSite(host string, site Site) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if site.Report.Host == "" {
+		site.Report.Host = host
+	}
+	s.sites[host] = &site
+}
+
+// AddFixture adds host, answering DefaultPolls times IN_PROGRESS then READY
+// with a Fixture report.
+func (s *Server) AddFixture(host, grade string, addrs ...string) {
+	s.AddSite(host, Site{Report: Fixture(host, grade, addrs...), Polls: DefaultPolls})
+}
+
+// SetQuota changes the number of concurrent assessments allowed
+func (s *Server) SetQuota(max int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.max = max
+}
+
+// FailNext makes the next n requests, whatever they are, fail with code
+// (e.g. 429 or 529).
+func (s *Server) FailNext(code, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, code)
+	}
+}
+
+// Requests returns how many times the call what (e.g. "analyze") was made
+func (s *Server) Requests(what string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[what]
+}
+
+// Fixture returns a READY report with one endpoint per address, all with grade
+func Fixture(host, grade string, addrs ...string) ssllabs.Host {
+	now := time.Now()
+
+	if len(addrs) == 0 {
+		addrs = []string{"192.0.2.1"}
+	}
+
+	lr := ssllabs.Host{
+		Host:            host,
+		Port:            443,
+		Protocol:        "http",
+		Status:          "READY",
+		StartTime:       ssllabs.NewTimestamp(now.Add(-time.Minute)),
+		TestTime:        ssllabs.NewTimestamp(now),
+		EngineVersion:   EngineVersion,
+		CriteriaVersion: CriteriaVersion,
+		CacheExpiryTime: ssllabs.NewTimestamp(now.Add(time.Hour)),
+	}
+
+	for _, ip := range addrs {
+		lr.Endpoints = append(lr.Endpoints, ssllabs.Endpoint{
+			IPAddress:         ip,
+			ServerName:        host,
+			StatusMessage:     "Ready",
+			Grade:             grade,
+			GradeTrustIgnored: grade,
+			Progress:          100,
+			Duration:          60000,
+			Delegation:        1,
+		})
+	}
+	return lr
+}
+
+// handle dispatches on the last part of the path so that BaseURL can have any prefix
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	what := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[what]++
+
+	w.Header().Set("X-Max-Assessments", strconv.Itoa(s.max))
+	w.Header().Set("X-Current-Assessments", strconv.Itoa(s.current))
+
+	if len(s.failures) != 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		fail(w, code, "", http.StatusText(code))
+		return
+	}
+
+	q := r.URL.Query()
+
+	switch what {
+	case "info":
+		reply(w, ssllabs.Info{
+			EngineVersion:        EngineVersion,
+			CriteriaVersion:      CriteriaVersion,
+			MaxAssessments:       s.max,
+			CurrentAssessments:   s.current,
+			NewAssessmentCoolOff: 0,
+			Messages:             []string{"ssllabstest fake server"},
+		})
+	case "analyze":
+		s.analyze(w, q)
+	case "getEndpointData":
+		s.endpointData(w, q)
+	case "getStatusCodes":
+		reply(w, ssllabs.StatusCodes{StatusDetails: statusDetails})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// analyze runs the script of the host, one step per call
+func (s *Server) analyze(w http.ResponseWriter, q map[string][]string) {
+	host := first(q["host"])
+	if host == "" {
+		fail(w, http.StatusBadRequest, "host", "qlue.validation.mandatory")
+		return
+	}
+
+	site, ok := s.sites[host]
+	if !ok {
+		site = &Site{Report: ssllabs.Host{Host: host}, Error: "Unable to resolve domain name"}
+		s.sites[host] = site
+	}
+
+	// Like SSLLabs, startNew does not restart an assessment in progress
+	if !site.running && (site.step == 0 || first(q["startNew"]) == "on") {
+		if s.current >= s.max {
+			fail(w, http.StatusTooManyRequests, "", fmt.Sprintf("Concurrent assessment limit reached (%d/%d)", s.current, s.max))
+			return
+		}
+		s.current++
+		site.running = true
+		site.step = 0
+	}
+
+	lr := site.answer(first(q["all"]))
+	if site.running && (lr.Status == "READY" || lr.Status == "ERROR") {
+		site.running = false
+		s.current--
+	}
+	reply(w, lr)
+}
+
+// answer returns the current state then moves to the next step
+func (site *Site) answer(all string) ssllabs.Host {
+	lr := site.Report
+	step := site.step
+
+	if site.running {
+		site.step++
+	}
+
+	switch {
+	case step == 0:
+		lr.Status = "DNS"
+		lr.StatusMessage = "Resolving domain names"
+		lr.Endpoints = nil
+	case step <= site.Polls:
+		lr.Status = "IN_PROGRESS"
+		lr.StatusMessage = ""
+		lr.Endpoints = make([]ssllabs.Endpoint, len(site.Report.Endpoints))
+		for i, e := range site.Report.Endpoints {
+			lr.Endpoints[i] = ssllabs.Endpoint{
+				IPAddress:     e.IPAddress,
+				ServerName:    e.ServerName,
+				StatusMessage: "In progress",
+				StatusDetails: "TESTING_PROTOCOLS",
+				Progress:      step * 100 / (site.Polls + 1),
+				Eta:           site.Polls - step + 1,
+				Delegation:    e.Delegation,
+			}
+		}
+	case site.Error != "":
+		lr.Status = "ERROR"
+		lr.StatusMessage = site.Error
+		lr.Endpoints = nil
+	default:
+		lr.Status = "READY"
+		if lr.StatusMessage == "" {
+			lr.StatusMessage = "Ready"
+		}
+		// Details are sent only if asked for
+		if all != "on" && all != "done" {
+			eps := make([]ssllabs.Endpoint, len(lr.Endpoints))
+			for i, e := range lr.Endpoints {
+				e.Details = ssllabs.EndpointDetails{}
+				eps[i] = e
+			}
+			lr.Endpoints = eps
+		}
+	}
+	return lr
+}
+
+// endpointData returns one endpoint of a finished assessment
+func (s *Server) endpointData(w http.ResponseWriter, q map[string][]string) {
+	host, ip := first(q["host"]), first(q["s"])
+	if host == "" {
+		fail(w, http.StatusBadRequest, "host", "qlue.validation.mandatory")
+		return
+	}
+
+	site, ok := s.sites[host]
+	if ok && site.Error == "" {
+		for _, e := range site.Report.Endpoints {
+			if ip == "" || e.IPAddress == ip {
+				reply(w, e)
+				return
+			}
+		}
+	}
+	fail(w, http.StatusBadRequest, "s", "qlue.validation.invalid")
+}
+
+// reply sends v as JSON
+func reply(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// fail sends an error payload the way SSLLabs does
+func fail(w http.ResponseWriter, code int, field, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ssllabs.LabsErrorResponse{
+		ResponseErrors: []ssllabs.LabsError{{Field: field, Message: msg}},
+	})
+}
+
+func first(v []string) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+// statusDetails is a subset of what getStatusCodes returns
+var statusDetails = map[string]string{
+	"TESTING_PROTOCOLS":          "Testing protocols",
+	"TESTING_HEARTBLEED":         "Testing Heartbleed",
+	"TESTING_HTTPS":              "Sending one complete HTTPS request",
+	"PREPARING_REPORT":           "Preparing the report",
+	"RETRIEVING_CERT_V3__SNI":    "Retrieving certificate",
+	"VALIDATING_TRUST_PATHS":     "Validating trust paths",
+	"TESTING_SESSION_TICKETS":    "Testing Session Ticket support",
+	"TESTING_RENEGOTIATION":      "Testing renegotiation",
+	"TESTING_CAPABILITIES":       "Determining server capabilities",
+	"TESTING_SESSION_RESUMPTION": "Testing session resumption",
+}
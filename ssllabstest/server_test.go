@@ -0,0 +1,161 @@
+package ssllabstest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keltia/ssllabs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Info(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.Equal(t, EngineVersion, info.EngineVersion)
+	assert.Equal(t, DefaultMaxAssessments, info.MaxAssessments)
+}
+
+func TestServer_GetGrade(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddFixture("example.com", "A+", "192.0.2.1", "2001:db8::1")
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	var evs []ssllabs.ProgressEvent
+
+	opts := ssllabs.DefaultAnalyzeOptions()
+	opts.Progress = func(ev ssllabs.ProgressEvent) {
+		evs = append(evs, ev)
+	}
+
+	grade, err := c.GetGradeWithOptions(context.Background(), "example.com", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "A+", grade)
+
+	// DNS, 2 polls then READY
+	require.Len(t, evs, 2+DefaultPolls)
+	assert.Equal(t, "DNS", evs[0].Status)
+	assert.Equal(t, "IN_PROGRESS", evs[1].Status)
+	assert.Equal(t, 33, evs[1].Progress())
+	assert.Equal(t, 66, evs[2].Progress())
+	assert.Equal(t, "READY", evs[3].Status)
+	assert.Equal(t, 4, srv.Requests("analyze"))
+
+	// Finished, we get the result at once
+	grades, err := c.GetGrades("example.com")
+	require.NoError(t, err)
+	assert.Len(t, grades, 2)
+	assert.Equal(t, 5, srv.Requests("analyze"))
+}
+
+func TestServer_Error(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	_, err = c.AnalyzeWithOptions(context.Background(), "unknown.example.com", ssllabs.DefaultAnalyzeOptions())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ssllabs.ErrAssessmentFailed))
+	assert.True(t, ssllabs.IsPermanent(err))
+}
+
+func TestServer_ScriptedError(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddSite("example.com", Site{Polls: 1, Error: "Unable to connect to the server"})
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	_, err = c.AnalyzeWithOptions(context.Background(), "example.com", ssllabs.DefaultAnalyzeOptions())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unable to connect to the server")
+	assert.Equal(t, 3, srv.Requests("analyze"))
+}
+
+func TestServer_StartNew(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddSite("example.com", Site{Report: Fixture("example.com", "B")})
+
+	cnf := srv.Config()
+	cnf.Force = true
+
+	c, err := ssllabs.NewClient(cnf)
+	require.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		grade, err := c.GetGradeWithOptions(context.Background(), "example.com", ssllabs.DefaultAnalyzeOptions())
+		require.NoError(t, err)
+		assert.Equal(t, "B", grade)
+		// trigger (DNS), then READY
+		assert.Equal(t, 2*i, srv.Requests("analyze"))
+	}
+}
+
+func TestServer_Quota(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddFixture("example.com", "A")
+	srv.SetQuota(0)
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	_, err = c.GetGradeWithOptions(context.Background(), "example.com", ssllabs.DefaultAnalyzeOptions())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ssllabs.ErrRateLimited))
+}
+
+func TestServer_FailNext(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.FailNext(529, 1)
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	_, err = c.GetStatusCodes()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ssllabs.ErrServiceOverloaded))
+
+	sc, err := c.GetStatusCodes()
+	require.NoError(t, err)
+	assert.NotEmpty(t, sc.StatusDetails)
+}
+
+func TestServer_GetEndpointData(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddFixture("example.com", "A", "192.0.2.1", "192.0.2.2")
+
+	c, err := ssllabs.NewClient(srv.Config())
+	require.NoError(t, err)
+
+	ep, err := c.GetEndpointDataWithOptions(context.Background(), "example.com", ssllabs.EndpointOptions{IPAddress: "192.0.2.2"})
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.2", ep.IPAddress)
+	assert.Equal(t, "A", ep.Grade)
+
+	_, err = c.GetEndpointDataWithOptions(context.Background(), "example.com", ssllabs.EndpointOptions{IPAddress: "192.0.2.3"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ssllabs.ErrInvalidHost))
+}
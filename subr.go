@@ -6,16 +6,23 @@ Package ssllabs contains SSLLabs-related functions.
 package ssllabs
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 const (
+	// HttpUnauthorized is sent by API v4 when the email is not registered
+	HttpUnauthorized = 441
+	// HttpRetryLater is sent when the service is overloaded
 	HttpRetryLater = 529
 )
 
@@ -36,59 +43,115 @@ func AddQueryParameters(baseURL string, queryParams map[string]string) string {
 }
 
 // prepareRequest insert all pre-defined stuff
-func (c *Client) prepareRequest(method, what string, opts map[string]string) (req *http.Request) {
+func (c *Client) prepareRequest(ctx context.Context, method, what string, body io.Reader, opts map[string]string) (req *http.Request) {
 	endPoint := fmt.Sprintf("%s/%s", c.baseurl, what)
 
 	baseURL := AddQueryParameters(endPoint, opts)
-	c.debug("Options:\n%v", opts)
-	c.debug("baseURL: %s", baseURL)
 
-	req, _ = http.NewRequest(method, baseURL, nil)
+	req, err := http.NewRequestWithContext(ctx, method, baseURL, body)
+	if err != nil {
+		return nil
+	}
 
-	c.debug("req=%#v", req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.useragent)
+
+	// API v4 authentication
+	if c.email != "" {
+		req.Header.Set("email", c.email)
+	}
 
 	return
 }
 
-func (c *Client) callAPI(what, sbody string, opts map[string]string) ([]byte, error) {
-	var body []byte
+func (c *Client) callAPI(ctx context.Context, what, sbody string, opts map[string]string) ([]byte, error) {
+	for retry := 0; ; retry++ {
+		body, resp, err := c.doRequest(ctx, what, sbody, opts, retry)
+		if err == nil {
+			return body, nil
+		}
+
+		// Do not bother if we have been cancelled
+		if ctx.Err() != nil {
+			return []byte{}, err
+		}
+
+		wait, again := c.retry.Backoff(retry, resp, err)
+		if !again {
+			return []byte{}, err
+		}
+
+		c.verbose("retrying", "what", what, "retry", retry, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return []byte{}, errors.Wrap(ctx.Err(), "callAPI/wait")
+		case <-time.After(wait):
+		}
+	}
+}
 
-	retry := 0
+// doRequest is one attempt at calling the API
+func (c *Client) doRequest(ctx context.Context, what, sbody string, opts map[string]string, retry int) ([]byte, *http.Response, error) {
+	var req *http.Request
 
-	c.debug("callAPI")
-	c.debug("clt=%#v", c.client)
-	c.debug("opts=%v", opts)
+	ctx, span := c.startSpan(ctx, "ssllabs."+what, attrRetry.Int(retry))
 
-	req := c.prepareRequest("GET", what, opts)
+	// Only register has a body
+	if sbody != "" {
+		req = c.prepareRequest(ctx, "POST", what, strings.NewReader(sbody), opts)
+	} else {
+		req = c.prepareRequest(ctx, "GET", what, nil, opts)
+	}
 	if req == nil {
-		return []byte{}, fmt.Errorf("nil req")
+		err := fmt.Errorf("nil req")
+		endSpan(span, err)
+		return []byte{}, nil, err
 	}
+	span.SetAttributes(attrMethod.String(req.Method), attrURL.String(req.URL.String()))
 
+	c.debug("request", "method", req.Method, "url", req.URL.String(), "retry", retry)
+	c.hooks.request(ctx, RequestEvent{Call: what, Method: req.Method, URL: req.URL.String(), Retry: retry})
+
+	ev := ResponseEvent{Call: what, Method: req.Method, URL: req.URL.String(), Retry: retry}
+
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
-		c.debug("err=%#v", err)
-		return []byte{}, errors.Wrap(err, "1st call")
+		ev.Duration, ev.Err = time.Since(start), err
+		c.debug("response", "url", ev.URL, "error", err, "duration", ev.Duration)
+		c.hooks.response(ctx, ev)
+		err = errors.Wrapf(err, "call, retry=%d", retry)
+		endSpan(span, err)
+		return []byte{}, nil, err
 	}
 	defer resp.Body.Close()
 
-	c.debug("resp=%#v", resp)
+	// Keep track of the assessments count
+	c.limiter.update(resp.Header)
 
-	if resp.StatusCode == HttpRetryLater {
-		c.debug("NOK/529")
-		return []byte{}, errors.Wrap(err, "529 received")
-	} else if resp.StatusCode == http.StatusOK {
+	body, err := ioutil.ReadAll(resp.Body)
+	ev.StatusCode, ev.Bytes, ev.Duration = resp.StatusCode, len(body), time.Since(start)
+	span.SetAttributes(attrStatusCode.Int(ev.StatusCode), attrBodySize.Int(ev.Bytes))
+	if err != nil {
+		ev.Err = err
+		c.hooks.response(ctx, ev)
+		err = errors.Wrapf(err, "body read, retry=%d", retry)
+		endSpan(span, err)
+		return []byte{}, resp, err
+	}
 
-		c.debug("status OK")
-		c.debug("read body")
+	c.debug("response", "url", ev.URL, "status", ev.StatusCode, "bytes", ev.Bytes, "duration", ev.Duration)
+	c.hooks.response(ctx, ev)
 
-		body, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return []byte{}, errors.Wrapf(err, "body read, retry=%d", retry)
-		}
-		return body, errors.Wrapf(err, "status: %v body: %q", resp.Status, body)
+	if resp.StatusCode != http.StatusOK {
+		err = newAPIError(resp.StatusCode, body)
+		endSpan(span, err)
+		return []byte{}, resp, err
 	}
-	c.debug("NOK")
-	return []byte{}, errors.Wrapf(err, "status: %d", resp.StatusCode)
+	endSpan(span, nil)
+	return body, resp, nil
 }
 
 // ParseResults unmarshals the json payload
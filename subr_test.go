@@ -1,8 +1,10 @@
 package ssllabs
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,7 +44,7 @@ func TestPrepareRequest(t *testing.T) {
 	require.NoError(t, err)
 
 	opts := map[string]string{}
-	req := c.prepareRequest("GET", "foo", opts)
+	req := c.prepareRequest(context.Background(), "GET", "foo", nil, opts)
 
 	assert.NotNil(t, req)
 	assert.IsType(t, (*http.Request)(nil), req)
@@ -52,6 +54,42 @@ func TestPrepareRequest(t *testing.T) {
 	assert.EqualValues(t, res, req.URL)
 }
 
+func TestPrepareRequestContext(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := c.prepareRequest(ctx, "GET", "foo", nil, map[string]string{})
+
+	require.NotNil(t, req)
+	assert.Equal(t, ctx, req.Context())
+}
+
+func TestPrepareRequestEmail(t *testing.T) {
+	c, err := NewClient(Config{APIVersion: 4, Email: "john@example.com"})
+	require.NoError(t, err)
+
+	req := c.prepareRequest(context.Background(), "GET", "foo", nil, map[string]string{})
+
+	require.NotNil(t, req)
+	assert.Equal(t, "john@example.com", req.Header.Get("email"))
+	assert.Empty(t, req.Header.Get("Content-Type"))
+}
+
+func TestPrepareRequestBody(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	req := c.prepareRequest(context.Background(), "POST", "foo", strings.NewReader("{}"), map[string]string{})
+
+	require.NotNil(t, req)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Empty(t, req.Header.Get("email"))
+}
+
 func TestLabsErrorResponse_Error(t *testing.T) {
 	var empty = "{\"errors\":null}"
 
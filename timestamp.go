@@ -0,0 +1,71 @@
+// timestamp.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Timestamp is a time sent by SSLLabs as milliseconds since the epoch.  0 is
+// the zero time.Time and the reverse, so that it is marshalled back as it came.
+type Timestamp struct {
+	time.Time
+}
+
+// NewTimestamp converts t, rounded to the millisecond like SSLLabs does
+func NewTimestamp(t time.Time) Timestamp {
+	if t.IsZero() {
+		return Timestamp{}
+	}
+	return Timestamp{time.UnixMilli(t.UnixMilli())}
+}
+
+// Millis returns the number of milliseconds since the epoch, 0 for the zero time
+func (t Timestamp) Millis() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(t.Millis(), 10)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, null is the zero time
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	ms, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "bad timestamp %s", string(b))
+	}
+
+	t.Time = time.Time{}
+	if ms != 0 {
+		t.Time = time.UnixMilli(ms)
+	}
+	return nil
+}
+
+// Age is how long ago the report was made, 0 if it has no test time
+func (lr *Host) Age() time.Duration {
+	if lr.TestTime.IsZero() {
+		return 0
+	}
+	return time.Since(lr.TestTime.Time)
+}
+
+// ExpiresIn is how long the certificate is still valid, negative once expired
+func (c *Cert) ExpiresIn() time.Duration {
+	return time.Until(c.NotAfter.Time)
+}
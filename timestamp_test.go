@@ -0,0 +1,87 @@
+package ssllabs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp_JSON(t *testing.T) {
+	td := []struct {
+		in   string
+		want time.Time
+		out  string
+	}{
+		{"1536094315704", time.Date(2018, 9, 4, 20, 51, 55, 704000000, time.UTC), "1536094315704"},
+		{"0", time.Time{}, "0"},
+		{"null", time.Time{}, "0"},
+		{"-1000", time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), "-1000"},
+	}
+
+	for _, d := range td {
+		var ts Timestamp
+
+		require.NoError(t, json.Unmarshal([]byte(d.in), &ts), d.in)
+		assert.True(t, d.want.Equal(ts.Time), d.in)
+
+		out, err := json.Marshal(ts)
+		require.NoError(t, err)
+		assert.Equal(t, d.out, string(out))
+	}
+}
+
+func TestTimestamp_Bad(t *testing.T) {
+	var ts Timestamp
+
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &ts))
+	assert.Error(t, json.Unmarshal([]byte(`1.5`), &ts))
+}
+
+func TestNewTimestamp(t *testing.T) {
+	now := time.Now()
+
+	ts := NewTimestamp(now)
+	assert.Equal(t, now.UnixMilli(), ts.Millis())
+	assert.Equal(t, now.Truncate(time.Millisecond).UnixNano(), ts.UnixNano())
+
+	assert.Equal(t, int64(0), NewTimestamp(time.Time{}).Millis())
+	assert.True(t, NewTimestamp(time.Time{}).IsZero())
+}
+
+func TestTimestamp_Report(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/ssllabs-full.json")
+	require.NoError(t, err)
+
+	var lr Host
+
+	require.NoError(t, json.Unmarshal(raw, &lr))
+	assert.Equal(t, int64(1536094265228), lr.StartTime.Millis())
+	assert.Equal(t, int64(1536094315704), lr.TestTime.Millis())
+	assert.True(t, lr.CacheExpiryTime.IsZero())
+	assert.Equal(t, int64(1556884800000), lr.Certs[0].NotAfter.Millis())
+
+	out, err := json.Marshal(lr)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"startTime":1536094265228`)
+	assert.Contains(t, string(out), `"cacheExpiryTime":0`)
+	assert.Contains(t, string(out), `"notAfter":1556884800000`)
+}
+
+func TestHost_Age(t *testing.T) {
+	assert.Equal(t, time.Duration(0), (&Host{}).Age())
+
+	lr := &Host{TestTime: NewTimestamp(time.Now().Add(-time.Hour))}
+	assert.InDelta(t, float64(time.Hour), float64(lr.Age()), float64(time.Minute))
+}
+
+func TestCert_ExpiresIn(t *testing.T) {
+	c := &Cert{NotAfter: NewTimestamp(time.Now().Add(30 * 24 * time.Hour))}
+	assert.InDelta(t, float64(30*24*time.Hour), float64(c.ExpiresIn()), float64(time.Minute))
+
+	c = &Cert{NotAfter: NewTimestamp(time.Now().Add(-time.Hour))}
+	assert.True(t, c.ExpiresIn() < 0)
+}
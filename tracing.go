@@ -0,0 +1,66 @@
+// tracing.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of our spans
+const tracerName = "github.com/keltia/ssllabs"
+
+// Attributes set on our spans & events
+const (
+	attrHost     = attribute.Key("ssllabs.host")
+	attrStartNew = attribute.Key("ssllabs.start_new")
+	attrCacheHit = attribute.Key("ssllabs.cache_hit")
+	attrStatus   = attribute.Key("ssllabs.status")
+	attrPoll     = attribute.Key("ssllabs.poll")
+	attrPolls    = attribute.Key("ssllabs.polls")
+	attrProgress = attribute.Key("ssllabs.progress")
+	attrRetry    = attribute.Key("ssllabs.retry")
+
+	attrMethod     = attribute.Key("http.request.method")
+	attrURL        = attribute.Key("url.full")
+	attrStatusCode = attribute.Key("http.response.status_code")
+	attrBodySize   = attribute.Key("http.response.body.size")
+)
+
+// newTracer uses the global provider if tp is nil, which does nothing unless
+// the application has set one.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName, trace.WithInstrumentationVersion(MyVersion))
+}
+
+// startSpan starts a client span, child of the one in ctx if any
+func (c *Client) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, and ends span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// pollEvent adds the status & progress of lr to span
+func pollEvent(span trace.Span, lr *Host, poll int) {
+	span.AddEvent("poll", trace.WithAttributes(
+		attrPoll.Int(poll),
+		attrStatus.String(lr.Status),
+		attrProgress.Int(newProgressEvent(lr, poll).Progress()),
+	))
+}
@@ -0,0 +1,119 @@
+package ssllabs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func tracedClient(t *testing.T, url string) (*Client, *tracetest.InMemoryExporter) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	c, err := NewClient(Config{
+		BaseURL:        url,
+		Poller:         NoWaitPoller{Budget: time.Minute},
+		RetryPolicy:    NoRetry{},
+		TracerProvider: tp,
+	})
+	require.NoError(t, err)
+	return c, exp
+}
+
+// attr returns the value of key in attrs
+func attr(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing_GetDetailedReport(t *testing.T) {
+	Before(t)
+
+	srv := scripted(polls...)
+	defer srv.Close()
+
+	c, exp := tracedClient(t, srv.URL)
+
+	_, err := c.GetDetailedReportWithOptions(context.Background(), "www.example.com", DefaultAnalyzeOptions())
+	require.NoError(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 6)
+
+	// Spans are exported when they end
+	top, an := spans[5], spans[4]
+	assert.Equal(t, "ssllabs.GetDetailedReport", top.Name)
+	assert.False(t, top.Parent.IsValid())
+	assert.Equal(t, codes.Unset, top.Status.Code)
+
+	assert.Equal(t, "ssllabs.Analyze", an.Name)
+	assert.Equal(t, top.SpanContext.SpanID(), an.Parent.SpanID())
+	assert.Equal(t, trace.SpanKindClient, an.SpanKind)
+	assert.Equal(t, "www.example.com", attr(an.Attributes, attrHost).AsString())
+	assert.Equal(t, "READY", attr(an.Attributes, attrStatus).AsString())
+	assert.Equal(t, int64(4), attr(an.Attributes, attrPolls).AsInt64())
+
+	require.Len(t, an.Events, 4)
+	for i, ev := range an.Events {
+		assert.Equal(t, "poll", ev.Name)
+		assert.Equal(t, int64(i), attr(ev.Attributes, attrPoll).AsInt64())
+	}
+	assert.Equal(t, "DNS", attr(an.Events[0].Attributes, attrStatus).AsString())
+	assert.Equal(t, "READY", attr(an.Events[3].Attributes, attrStatus).AsString())
+	assert.Equal(t, attribute.INT64, attr(an.Events[3].Attributes, attrProgress).Type())
+
+	for _, s := range spans[:4] {
+		assert.Equal(t, "ssllabs.analyze", s.Name)
+		assert.Equal(t, an.SpanContext.SpanID(), s.Parent.SpanID())
+		assert.Equal(t, "GET", attr(s.Attributes, attrMethod).AsString())
+		assert.Contains(t, attr(s.Attributes, attrURL).AsString(), "host=www.example.com")
+		assert.Equal(t, int64(http.StatusOK), attr(s.Attributes, attrStatusCode).AsInt64())
+	}
+}
+
+func TestTracing_Error(t *testing.T) {
+	Before(t)
+
+	srv := scripted(`{"host":"nowhere.example.com","status":"ERROR","statusMessage":"Unable to resolve domain name"}`)
+	defer srv.Close()
+
+	c, exp := tracedClient(t, srv.URL)
+
+	_, err := c.AnalyzeWithOptions(context.Background(), "nowhere.example.com", DefaultAnalyzeOptions())
+	require.Error(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+
+	an := spans[1]
+	assert.Equal(t, "ssllabs.Analyze", an.Name)
+	assert.Equal(t, codes.Error, an.Status.Code)
+	assert.Equal(t, "ERROR", attr(an.Attributes, attrStatus).AsString())
+
+	// The poll event, then the error
+	require.Len(t, an.Events, 2)
+	assert.Equal(t, "exception", an.Events[1].Name)
+}
+
+func TestTracing_Default(t *testing.T) {
+	c, err := NewClient()
+	require.NoError(t, err)
+
+	// The global provider does nothing by default
+	_, span := c.startSpan(context.Background(), "test")
+	assert.False(t, span.IsRecording())
+	span.End()
+}
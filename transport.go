@@ -0,0 +1,90 @@
+// transport.go
+//
+// Copyright 2018 © by Ollivier Robert <roberto@keltia.net>
+
+package ssllabs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/keltia/proxy"
+	"github.com/pkg/errors"
+)
+
+// setupHTTP creates the http.Client from, in order of precedence, Config.HTTPClient,
+// Config.Transport, Config.Proxy or the environment (HTTPS_PROXY & co and .netrc).
+// The Cassette recorder, if any, goes on top.
+func (c *Client) setupHTTP(cnf Config) error {
+	var rt http.RoundTripper
+
+	switch {
+	case cnf.HTTPClient != nil:
+		rt = cnf.HTTPClient.Transport
+	case cnf.Transport != nil:
+		rt = cnf.Transport
+	case cnf.Proxy != "":
+		trsp, err := proxyTransport(cnf.Proxy, cnf.ProxyUser, cnf.ProxyPassword)
+		if err != nil {
+			return err
+		}
+		c.proxyauth = cnf.ProxyUser != ""
+		rt = trsp
+	default:
+		// We do not care whether it fails or not, if it does, just no proxyauth.
+		// Only remember that we have some, not the credentials.
+		proxyauth, _ := proxy.SetupProxyAuth()
+		c.proxyauth = proxyauth != ""
+
+		_, trsp := proxy.SetupTransport(c.baseurl)
+		rt = trsp
+	}
+
+	if cnf.Cassette != "" {
+		next := rt
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		rec, err := NewRecorder(cnf.Cassette, cnf.CassetteMode, next)
+		if err != nil {
+			return err
+		}
+		rt = rec
+	}
+
+	// Never modify the caller's client
+	if cnf.HTTPClient != nil {
+		hc := *cnf.HTTPClient
+		hc.Transport = rt
+		c.client = &hc
+		return nil
+	}
+
+	c.client = &http.Client{
+		Transport:     rt,
+		Timeout:       c.timeout,
+		CheckRedirect: myRedirect,
+	}
+	return nil
+}
+
+// proxyTransport goes through the given proxy, with authentication if user is set
+func proxyTransport(proxyURL, user, password string) (*http.Transport, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad proxy")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("bad proxy %q", proxyURL)
+	}
+
+	// net/http sends Proxy-Authorization itself, for CONNECT too
+	if user != "" {
+		u.User = url.UserPassword(user, password)
+	}
+
+	trsp := http.DefaultTransport.(*http.Transport).Clone()
+	trsp.Proxy = http.ProxyURL(u)
+	return trsp, nil
+}
@@ -0,0 +1,134 @@
+package ssllabs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rtFunc makes a RoundTripper out of a function
+type rtFunc func(*http.Request) (*http.Response, error)
+
+func (f rtFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// infoRT answers everything with info.json and keeps the last request
+func infoRT(t *testing.T, last **http.Request) rtFunc {
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+
+	return func(req *http.Request) (*http.Response, error) {
+		*last = req
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(string(fti))),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestClient_Transport(t *testing.T) {
+	var last *http.Request
+
+	c, err := NewClient(Config{Transport: infoRT(t, &last), UserAgent: "myapp/1.0"})
+	require.NoError(t, err)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.Equal(t, 25, info.MaxAssessments)
+
+	require.NotNil(t, last)
+	assert.Equal(t, "myapp/1.0", last.Header.Get("User-Agent"))
+	assert.Equal(t, DefaultWait, c.client.Timeout)
+}
+
+func TestClient_HTTPClient(t *testing.T) {
+	var last *http.Request
+
+	hc := &http.Client{Transport: infoRT(t, &last)}
+
+	c, err := NewClient(Config{HTTPClient: hc})
+	require.NoError(t, err)
+
+	_, err = c.Info()
+	require.NoError(t, err)
+
+	require.NotNil(t, last)
+	assert.Equal(t, fmt.Sprintf("%s/%s", MyName, MyVersion), last.Header.Get("User-Agent"))
+	assert.Equal(t, hc.Timeout, c.client.Timeout)
+	assert.False(t, hc == c.client)
+}
+
+// Cassette still works on top of our transport
+func TestClient_HTTPClientCassette(t *testing.T) {
+	var last *http.Request
+
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	hc := &http.Client{Transport: infoRT(t, &last)}
+
+	c, err := NewClient(Config{HTTPClient: hc, Cassette: dir, CassetteMode: ModeRecord})
+	require.NoError(t, err)
+
+	_, err = c.Info()
+	require.NoError(t, err)
+	assert.NotNil(t, last)
+	assert.IsType(t, rtFunc(nil), hc.Transport)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestClient_Proxy(t *testing.T) {
+	fti, err := ioutil.ReadFile("testdata/info.json")
+	require.NoError(t, err)
+
+	var (
+		auth string
+		uri  string
+	)
+
+	// Plain HTTP through a proxy sends the full URL
+	prx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Proxy-Authorization")
+		uri = r.RequestURI
+		w.Write(fti)
+	}))
+	defer prx.Close()
+
+	c, err := NewClient(Config{
+		BaseURL:       "http://api.example.com/api/v3",
+		Proxy:         prx.URL,
+		ProxyUser:     "john",
+		ProxyPassword: "secret",
+	})
+	require.NoError(t, err)
+
+	_, err = c.Info()
+	require.NoError(t, err)
+	assert.Equal(t, "Basic am9objpzZWNyZXQ=", auth)
+	assert.Equal(t, "http://api.example.com/api/v3/info", uri)
+	assert.True(t, c.proxyauth)
+}
+
+func TestClient_BadProxy(t *testing.T) {
+	c, err := NewClient(Config{Proxy: "localhost"})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+
+	c, err = NewClient(Config{Proxy: "http://[::1"})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
@@ -3,7 +3,7 @@
 /*
 Package ssllabs These are the types used by SSLLabs/Qualys
 
-This is for API v3
+This is for API v3 & v4
 */
 package ssllabs
 
@@ -31,6 +31,20 @@ func (e LabsErrorResponse) Error() string {
 	return string(msg)
 }
 
+// Registration is what the v4 register call needs
+type Registration struct {
+	FirstName    string `json:"firstName"`
+	LastName     string `json:"lastName"`
+	Email        string `json:"email"`
+	Organization string `json:"organization"`
+}
+
+// RegisterResponse is the answer to register
+type RegisterResponse struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
 // Info describes the current SSLLabs engine used
 type Info struct {
 	EngineVersion        string `json:"engineVersion"`
@@ -48,15 +62,22 @@ type Host struct {
 	Protocol        string
 	IsPublic        bool `json:"isPublic"`
 	Status          string
-	StatusMessage   string   `json:"statusMessage"`
-	StartTime       int64    `json:"startTime"`
-	TestTime        int64    `json:"testTime"`
-	EngineVersion   string   `json:"engineVersion"`
-	CriteriaVersion string   `json:"criteriaVersion"`
-	CacheExpiryTime int64    `json:"cacheExpiryTime"`
-	CertHostnames   []string `json:"certHostnames"`
+	StatusMessage   string    `json:"statusMessage"`
+	StartTime       Timestamp `json:"startTime"`
+	TestTime        Timestamp `json:"testTime"`
+	EngineVersion   string    `json:"engineVersion"`
+	CriteriaVersion string    `json:"criteriaVersion"`
+	CacheExpiryTime Timestamp `json:"cacheExpiryTime"`
+	CertHostnames   []string  `json:"certHostnames"`
 	Endpoints       []Endpoint
 	Certs           []Cert `json:"certs,omitempty"`
+
+	// Raw is the JSON we got, Extra the members we do not know about
+	Raw   json.RawMessage            `json:"-"`
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// Drift is set with Config.StrictDecode if the JSON did not match
+	Drift *SchemaDrift `json:"-"`
 }
 
 // Endpoint is an Endpoint (IPv4, IPv6)
@@ -76,60 +97,69 @@ type Endpoint struct {
 	Eta                  int
 	Delegation           int
 	Details              EndpointDetails `json:"details,omitempty"`
+
+	// Raw is the JSON we got, Extra the members we do not know about
+	Raw   json.RawMessage            `json:"-"`
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// Drift is set with Config.StrictDecode if the JSON did not match
+	Drift *SchemaDrift `json:"-"`
 }
 
 // EndpointDetails gives the details of a given Endpoint
 type EndpointDetails struct {
-	HostStartTime                  int64              `json:"hostStartTime"`
+	HostStartTime                  Timestamp          `json:"hostStartTime"`
 	CertChains                     []CertificateChain `json:"certChains"`
 	Protocols                      []Protocol
 	Suites                         []ProtocolSuites
-	NoSniSuites                    ProtocolSuites `json:"noSniSuites"`
-	NamedGroups                    NamedGroups    `json:"namedGroups"`
-	ServerSignature                string         `json:"serverSignature"`
-	PrefixDelegation               bool           `json:"prefixDelegation"`
-	NonPrefixDelegation            bool           `json:"nonPrefixDelegation"`
-	VulnBeast                      bool           `json:"vulnBeast"`
-	RenegSupport                   int            `json:"renegSupport"`
-	SessionResumption              int            `json:"sessionResumption"`
-	CompressionMethods             int            `json:"compressionMethods"`
-	SupportsNpn                    bool           `json:"supportsNpn"`
-	NpnProcotols                   string         `json:"npnProtocols"`
-	SupportsAlpn                   bool           `json:"supportsAlpn"`
+	NoSniSuites                    ProtocolSuites    `json:"noSniSuites"`
+	NamedGroups                    NamedGroups       `json:"namedGroups"`
+	ServerSignature                string            `json:"serverSignature"`
+	PrefixDelegation               bool              `json:"prefixDelegation"`
+	NonPrefixDelegation            bool              `json:"nonPrefixDelegation"`
+	VulnBeast                      bool              `json:"vulnBeast"`
+	RenegSupport                   RenegSupport      `json:"renegSupport"`
+	SessionResumption              SessionResumption `json:"sessionResumption"`
+	CompressionMethods             int               `json:"compressionMethods"`
+	SupportsNpn                    bool              `json:"supportsNpn"`
+	NpnProcotols                   string            `json:"npnProtocols"`
+	SupportsAlpn                   bool              `json:"supportsAlpn"`
 	AlpnProtocols                  string
-	SessionTickets                 int    `json:"sessionTickets"`
-	OcspStapling                   bool   `json:"ocspStapling"`
-	StaplingRevocationStatus       int    `json:"staplingRevocationStatus"`
-	StaplingRevocationErrorMessage string `json:"staplingRevocationErrorMessage"`
-	SniRequired                    bool   `json:"sniRequired"`
-	HTTPStatusCode                 int    `json:"httpStatusCode"`
-	HTTPForwarding                 string `json:"httpForwarding"`
-	SupportsRC4                    bool   `json:"supportsRc4"`
-	RC4WithModern                  bool   `json:"rc4WithModern"`
-	RC4Only                        bool   `json:"rc4Only"`
-	ForwardSecrecy                 int    `json:"forwardSecrecy"`
-	ProtocolIntolerance            int    `json:"protocolIntolerance"`
-	MiscIntolerance                int    `json:"miscIntolerance"`
+	SessionTickets                 SessionTickets      `json:"sessionTickets"`
+	OcspStapling                   bool                `json:"ocspStapling"`
+	StaplingRevocationStatus       RevocationStatus    `json:"staplingRevocationStatus"`
+	StaplingRevocationErrorMessage string              `json:"staplingRevocationErrorMessage"`
+	SniRequired                    bool                `json:"sniRequired"`
+	HTTPStatusCode                 int                 `json:"httpStatusCode"`
+	HTTPForwarding                 string              `json:"httpForwarding"`
+	SupportsRC4                    bool                `json:"supportsRc4"`
+	RC4WithModern                  bool                `json:"rc4WithModern"`
+	RC4Only                        bool                `json:"rc4Only"`
+	ForwardSecrecy                 ForwardSecrecy      `json:"forwardSecrecy"`
+	SupportsAead                   bool                `json:"supportsAead"`
+	SupportsCBC                    bool                `json:"supportsCBC"`
+	ProtocolIntolerance            ProtocolIntolerance `json:"protocolIntolerance"`
+	MiscIntolerance                MiscIntolerance     `json:"miscIntolerance"`
 	Sims                           SimDetails
 	Heartbleed                     bool
 	Heartbeat                      bool
-	OpenSSLCcs                     int `json:"openSslCcs"`
-	OpenSSLLuckyMinus20            int `json:"openSSLLuckyMinus20"`
-	Ticketbleed                    int `json:"ticketbleed"`
-	Bleichenbacher                 int `json:"bleichenbacher"`
-	ZombiePoodle                   int `json:"zombiePoodle"`
-	GoldenPoodle                   int `json:"goldenPoodle"`
-	ZeroLengthPaddingOracle        int `json:"zeroLengthPaddingOracle"`
-	SleepingPoodle                 int `json:"sleepingPoodle"`
+	OpenSSLCcs                     OpenSSLCcs     `json:"openSslCcs"`
+	OpenSSLLuckyMinus20            LuckyMinus20   `json:"openSSLLuckyMinus20"`
+	Ticketbleed                    Ticketbleed    `json:"ticketbleed"`
+	Bleichenbacher                 Bleichenbacher `json:"bleichenbacher"`
+	ZombiePoodle                   PaddingOracle  `json:"zombiePoodle"`
+	GoldenPoodle                   PaddingOracle  `json:"goldenDoodle"`
+	ZeroLengthPaddingOracle        PaddingOracle  `json:"zeroLengthPaddingOracle"`
+	SleepingPoodle                 PaddingOracle  `json:"sleepingPoodle"`
 	Poodle                         bool
-	PoodleTLS                      int  `json:"poodleTLS"`
-	FallbackScsv                   bool `json:"fallbackScsv"`
+	PoodleTLS                      PoodleTLS `json:"poodleTls"`
+	FallbackScsv                   bool      `json:"fallbackScsv"`
 	Freak                          bool
-	HasSct                         int      `json:"hasSct"`
-	DhPrimes                       []string `json:"dhPrimes"`
-	DhUsesKnownPrimes              int      `json:"dhUsesKnownPrimes"`
-	DhYsReuse                      bool     `json:"dhYsReuse"`
-	EcdhParameterReuse             bool     `json:"ecdhParameterReuse"`
+	HasSct                         SctSources    `json:"hasSct"`
+	DhPrimes                       []string      `json:"dhPrimes"`
+	DhUsesKnownPrimes              DhKnownPrimes `json:"dhUsesKnownPrimes"`
+	DhYsReuse                      bool          `json:"dhYsReuse"`
+	EcdhParameterReuse             bool          `json:"ecdhParameterReuse"`
 	Logjam                         bool
 	ChaCha20Preference             bool
 	HstsPolicy                     HstsPolicy        `json:"hstsPolicy"`
@@ -141,14 +171,19 @@ type EndpointDetails struct {
 	DrownHosts                     []DrownHost       `json:"drownHosts"`
 	DrownErrors                    bool              `json:"drownErrors"`
 	DrownVulnerable                bool              `json:"drownVulnerable"`
+	ImplementsTLS13MandatoryCS     bool              `json:"implementsTLS13MandatoryCS"`
+	ZeroRTTEnabled                 ZeroRTT           `json:"zeroRTTEnabled"`
+
+	// Extra are the members we do not know about
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // CertificateChain is the list of certificates
 type CertificateChain struct {
 	ID         string
 	CertIds    []string    `json:"certIds"`
-	Trustpaths []TrustPath `json:"trustpaths"`
-	Issues     int
+	Trustpaths []TrustPath `json:"trustPaths"`
+	Issues     ChainIssues
 	NoSni      bool `json:"noSni"`
 }
 
@@ -179,9 +214,10 @@ type Protocol struct {
 
 // ProtocolSuites is a set of protocols
 type ProtocolSuites struct {
-	Protocol   int
-	List       []Suite
-	Preference bool
+	Protocol           int
+	List               []Suite
+	Preference         bool
+	ChaCha20Preference bool `json:"chaCha20Preference"`
 }
 
 // Suite describes a single protocol
@@ -191,6 +227,7 @@ type Suite struct {
 	CipherStrength int    `json:"cipherStrength"`
 	KxType         string `json:"kxType"`
 	KxStrength     int    `json:"kxStrength"`
+	DhBits         int    `json:"dhBits"`
 	DHP            int    `json:"dhP"`
 	DHG            int    `json:"dhG"`
 	DHYs           int    `json:"dhYs"`
@@ -208,9 +245,10 @@ type NamedGroups struct {
 
 // NamedGroup is a group
 type NamedGroup struct {
-	ID   int
-	Name string
-	Bits int
+	ID             int
+	Name           string
+	Bits           int
+	NamedGroupType string `json:"namedGroupType"`
 }
 
 // SimDetails are the result of simulation
@@ -268,10 +306,10 @@ type HstsPolicy struct {
 // HstsPreload is for HSTS preloading
 type HstsPreload struct {
 	Source     string
-	HostName   string `json:"hostName"`
+	HostName   string `json:"hostname"`
 	Status     string
 	Error      string
-	SourceTime int64 `json:"sourceTime"`
+	SourceTime Timestamp `json:"sourceTime"`
 }
 
 // HpkpPolicy describes the HPKP policy
@@ -306,8 +344,8 @@ type HTTPTransaction struct {
 	RequestLine       string       `json:"requestLine"`
 	RequestHeaders    []string     `json:"requestHeaders"`
 	ResponseLine      string       `json:"responseLine"`
-	ResponseRawHeader []string     `json:"responseRawHeader"`
-	ResponseHeader    []HTTPHeader `json:"responseHeader"`
+	ResponseRawHeader []string     `json:"responseHeadersRaw"`
+	ResponseHeader    []HTTPHeader `json:"responseHeaders"`
 	FragileServer     bool         `json:"fragileServer"`
 }
 
@@ -331,25 +369,25 @@ type DrownHost struct {
 type Cert struct {
 	ID                     string
 	Subject                string
-	SerialNumber           string    `json:"serialNumber"`
-	CommonNames            []string  `json:"commonNames"`
-	AltNames               []string  `json:"altNames"`
-	NotBefore              int64     `json:"notBefore"`
-	NotAfter               int64     `json:"notAfter"`
-	IssuerSubject          string    `json:"issuerSubject"`
-	SigAlg                 string    `json:"sigAlg"`
-	RevocationInfo         int       `json:"revocationInfo"`
-	CrlURIs                []string  `json:"crlURIs"`
-	OcspURIs               []string  `json:"ocspURIs"`
-	RevocationStatus       int       `json:"revocationStatus"`
-	CrlRevocationStatus    int       `json:"crlRevocationStatus"`
-	OcspRevocationStatus   int       `json:"ocspRevocationStatus"`
-	DNSCaa                 bool      `json:"dnsCaa"`
-	CaaPolicy              CaaPolicy `json:"caaPolicy"`
-	MustStaple             bool      `json:"mustStaple"`
+	SerialNumber           string           `json:"serialNumber"`
+	CommonNames            []string         `json:"commonNames"`
+	AltNames               []string         `json:"altNames"`
+	NotBefore              Timestamp        `json:"notBefore"`
+	NotAfter               Timestamp        `json:"notAfter"`
+	IssuerSubject          string           `json:"issuerSubject"`
+	SigAlg                 string           `json:"sigAlg"`
+	RevocationInfo         RevocationInfo   `json:"revocationInfo"`
+	CrlURIs                []string         `json:"crlURIs"`
+	OcspURIs               []string         `json:"ocspURIs"`
+	RevocationStatus       RevocationStatus `json:"revocationStatus"`
+	CrlRevocationStatus    RevocationStatus `json:"crlRevocationStatus"`
+	OcspRevocationStatus   RevocationStatus `json:"ocspRevocationStatus"`
+	DNSCaa                 bool             `json:"dnsCaa"`
+	CaaPolicy              CaaPolicy        `json:"caaPolicy"`
+	MustStaple             bool             `json:"mustStaple"`
 	Sgc                    int
 	ValidationType         string `json:"validationType"`
-	Issues                 int
+	Issues                 CertIssues
 	Sct                    bool
 	SHA1Hash               string `json:"sha1Hash"`
 	SHA256Hash             string `json:"sha256Hash"`
@@ -389,10 +427,17 @@ type HpkpDirective struct {
 // Hosts is a shortcut to all Host
 type Hosts []Host
 
+// LabsResult is the outcome of one site in a bulk run
+type LabsResult struct {
+	Site   string
+	Report *Host
+	Err    error
+}
+
 // LabsResults are all the result of a run w/ 1 or more sites
 type LabsResults struct {
-	reports   []Host
-	responses []string
+	Reports []Host
+	Errors  map[string]error
 }
 
 // StatusCodes describes all possible status code & translations
@@ -0,0 +1,66 @@
+package ssllabs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointDetails_TLS13(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/ssllabs-tls13.json")
+	require.NoError(t, err)
+
+	var ep Endpoint
+
+	require.NoError(t, json.Unmarshal(raw, &ep))
+
+	d := ep.Details
+	assert.True(t, d.SupportsAead)
+	assert.False(t, d.SupportsCBC)
+	assert.True(t, d.ImplementsTLS13MandatoryCS)
+	assert.Equal(t, ZeroRTTDisabled, d.ZeroRTTEnabled)
+	assert.Equal(t, PoodleTLSNotVulnerable, d.PoodleTLS)
+	assert.Equal(t, PaddingOracleNotVulnerable, d.ZombiePoodle)
+	assert.Equal(t, GoldenVulnerable, d.GoldenPoodle)
+
+	require.Len(t, d.Suites, 1)
+	assert.True(t, d.Suites[0].ChaCha20Preference)
+	assert.Equal(t, "x25519", d.Suites[0].List[0].NamedGroudName)
+
+	require.Len(t, d.NamedGroups.List, 2)
+	assert.Equal(t, "EC", d.NamedGroups.List[0].NamedGroupType)
+	assert.Equal(t, "DH", d.NamedGroups.List[1].NamedGroupType)
+
+	require.Len(t, d.HstsPreloads, 2)
+	assert.Equal(t, "Chrome", d.HstsPreloads[0].Source)
+	assert.Equal(t, int64(1577750400000), d.HstsPreloads[0].SourceTime.Millis())
+
+	require.Len(t, d.HTTPTransactions, 1)
+	tr := d.HTTPTransactions[0]
+	assert.Len(t, tr.ResponseRawHeader, 2)
+	require.Len(t, tr.ResponseHeader, 2)
+	assert.Equal(t, "Strict-Transport-Security", tr.ResponseHeader[1].Name)
+}
+
+func TestZeroRTT_String(t *testing.T) {
+	assert.Equal(t, "enabled", ZeroRTTEnabled.String())
+	assert.Equal(t, "not-tested", ZeroRTTNotTested.String())
+	assert.Equal(t, "ZeroRTT(5)", ZeroRTT(5).String())
+}
+
+func TestEndpointDetails_GoldenPoodle(t *testing.T) {
+	var d EndpointDetails
+
+	// As saved before the tag was fixed
+	require.NoError(t, json.Unmarshal([]byte(`{"goldenPoodle":5,"renegSupport":2}`), &d))
+	assert.Equal(t, GoldenExploitable, d.GoldenPoodle)
+	assert.Nil(t, d.Extra)
+
+	out, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"goldenDoodle":5`)
+	assert.NotContains(t, string(out), "goldenPoodle")
+}
@@ -6,7 +6,6 @@ package ssllabs
 
 import (
 	"fmt"
-	"log"
 	"time"
 )
 
@@ -18,16 +17,12 @@ func toDuration(t int) time.Duration {
 	return d
 }
 
-// debug displays only if fDebug is set
-func (c *Client) debug(str string, a ...interface{}) {
-	if c.level >= 2 {
-		log.Printf(str, a...)
-	}
+// debug sends a debug record, kv being key/value pairs
+func (c *Client) debug(msg string, kv ...interface{}) {
+	c.logger.Debug(msg, kv...)
 }
 
-// debug displays only if fVerbose is set
-func (c *Client) verbose(str string, a ...interface{}) {
-	if c.level >= 1 {
-		log.Printf(str, a...)
-	}
+// verbose sends an info record, kv being key/value pairs
+func (c *Client) verbose(msg string, kv ...interface{}) {
+	c.logger.Info(msg, kv...)
 }